@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// AuditSign 签到
+	AuditSign = "sign"
+	// AuditMusicianSign 音乐人签到
+	AuditMusicianSign = "musician_sign"
+	// AuditShare 分享歌曲
+	AuditShare = "share"
+	// AuditEventSend 发送动态
+	AuditEventSend = "event_send"
+	// AuditEventDelete 删除动态
+	AuditEventDelete = "event_delete"
+	// AuditCommentAdd 发送评论
+	AuditCommentAdd = "comment_add"
+	// AuditCommentReply 回复评论
+	AuditCommentReply = "comment_reply"
+	// AuditCommentDelete 删除评论
+	AuditCommentDelete = "comment_delete"
+	// AuditMessage 发送私信
+	AuditMessage = "message"
+	// AuditMlogSend 发送 Mlog
+	AuditMlogSend = "mlog_send"
+	// AuditMlogDelete 删除 Mlog
+	AuditMlogDelete = "mlog_delete"
+	// AuditBeanClaim 领取云豆
+	AuditBeanClaim = "bean_claim"
+	// AuditVipReward 领取会员成长值
+	AuditVipReward = "vip_reward"
+)
+
+// AuditRecord 审计日志记录
+type AuditRecord struct {
+	Time      time.Time      `json:"Time"`
+	AccountID int            `json:"AccountID"`
+	Nickname  string         `json:"Nickname,omitempty"`
+	Action    string         `json:"Action"`
+	Targets   map[string]int `json:"Targets,omitempty"`
+	Content   string         `json:"Content,omitempty"`
+	Code      int            `json:"Code"`
+	Message   string         `json:"Message,omitempty"`
+	Error     string         `json:"Error,omitempty"`
+}
+
+var auditMutex sync.Mutex
+
+// auditFile 返回审计日志路径
+func auditFile() string {
+	if config.AuditLog != "" {
+		return configRelPath(config.AuditLog)
+	}
+	return dataPath("audit.jsonl")
+}
+
+// writeAudit 追加一条审计日志
+func writeAudit(record AuditRecord) {
+	if record.Time.IsZero() {
+		record.Time = time.Now()
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		log.Errorf("写入审计日志失败: %v", err)
+		return
+	}
+	auditMutex.Lock()
+	defer auditMutex.Unlock()
+	file := auditFile()
+	err = os.MkdirAll(filepath.Dir(file), os.ModePerm)
+	if err != nil {
+		log.Errorf("写入审计日志失败: %v", err)
+		return
+	}
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		log.Errorf("写入审计日志失败: %v", err)
+		return
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	if err != nil {
+		log.Errorf("写入审计日志失败: %v", err)
+	}
+}
+
+// runAuditCommand 按条件筛选并输出审计日志
+func runAuditCommand(args []string) int {
+	fs := flag.NewFlagSet("audit", flag.ExitOnError)
+	account := fs.Int("account", 0, "Filter by account ID")
+	action := fs.String("action", "", "Filter by action (comma separated)")
+	since := fs.String("since", "", "Only records after this time (2006-01-02 or RFC3339)")
+	until := fs.String("until", "", "Only records before this time (2006-01-02 or RFC3339)")
+	failed := fs.Bool("failed", false, "Only failed records")
+	asJSON := fs.Bool("json", false, "Print raw JSON lines")
+	_ = fs.Parse(args)
+
+	var sinceTime, untilTime time.Time
+	var err error
+	if *since != "" {
+		if sinceTime, err = parseAuditTime(*since); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+	if *until != "" {
+		if untilTime, err = parseAuditTime(*until); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+	actions := map[string]bool{}
+	for _, a := range strings.Split(*action, ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			actions[a] = true
+		}
+	}
+
+	f, err := os.Open(auditFile())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var r AuditRecord
+		if json.Unmarshal(scanner.Bytes(), &r) != nil {
+			continue
+		}
+		if *account != 0 && r.AccountID != *account {
+			continue
+		}
+		if len(actions) != 0 && !actions[r.Action] {
+			continue
+		}
+		if !sinceTime.IsZero() && r.Time.Before(sinceTime) {
+			continue
+		}
+		if !untilTime.IsZero() && !r.Time.Before(untilTime) {
+			continue
+		}
+		if *failed && r.Code == 200 && r.Error == "" {
+			continue
+		}
+		if *asJSON {
+			fmt.Println(scanner.Text())
+			continue
+		}
+		fmt.Printf("%s  %-10d %-14s code=%-4d %s %s %s\n",
+			r.Time.Local().Format("2006/01/02 15:04:05"), r.AccountID, r.Action, r.Code,
+			formatTargets(r.Targets), quoteIfNotEmpty(r.Content), r.Message+r.Error)
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// formatTargets 按键名排序输出操作目标, 如 commentID=1,songID=2
+func formatTargets(targets map[string]int) string {
+	keys := make([]string, 0, len(targets))
+	for k := range targets {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for i, k := range keys {
+		keys[i] = fmt.Sprintf("%s=%d", k, targets[k])
+	}
+	return strings.Join(keys, ",")
+}
+
+func parseAuditTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", s, time.Local)
+}
+
+func quoteIfNotEmpty(s string) string {
+	if s == "" {
+		return ""
+	}
+	return fmt.Sprintf("%q", s)
+}
@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+func TestFormatTargets(t *testing.T) {
+	tests := []struct {
+		name    string
+		targets map[string]int
+		want    string
+	}{
+		{"无目标", nil, ""},
+		{"单个目标", map[string]int{"songID": 1}, "songID=1"},
+		{"按键名排序", map[string]int{"threadID": 3, "commentID": 1, "songID": 2}, "commentID=1,songID=2,threadID=3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 10; i++ {
+				if got := formatTargets(tt.targets); got != tt.want {
+					t.Fatalf("formatTargets() = %q, want %q", got, tt.want)
+				}
+			}
+		})
+	}
+}
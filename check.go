@@ -0,0 +1,333 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/XiaoMengXinX/Music163Api-Go/api"
+	"github.com/XiaoMengXinX/Music163Api-Go/utils"
+)
+
+const (
+	// TargetSong 歌曲
+	TargetSong = "song"
+	// TargetComment 评论所在的歌曲评论区
+	TargetComment = "comment"
+	// TargetUser 私信对象
+	TargetUser = "user"
+)
+
+const (
+	// checkCommentPages 检查评论时最多查找的页数
+	checkCommentPages = 5
+	// checkCommentPageSize 检查评论时每页的评论数
+	checkCommentPageSize = 100
+)
+
+// targetNames 检查目标类型的名称
+var targetNames = map[string]string{
+	TargetSong:    "歌曲",
+	TargetComment: "评论",
+	TargetUser:    "用户",
+}
+
+// TargetCheck 配置的歌曲、评论、私信对象的检查结果
+type TargetCheck struct {
+	Type    string `json:"Type"`
+	ID      int    `json:"ID"`
+	Usage   string `json:"Usage"`
+	Name    string `json:"Name,omitempty"`
+	OK      bool   `json:"OK"`
+	Error   string `json:"Error,omitempty"`
+	Warning string `json:"Warning,omitempty"`
+}
+
+// AccountHealth 账号健康检查结果
+type AccountHealth struct {
+	User        int           `json:"User"`
+	UserID      int           `json:"UserID"`
+	Nickname    string        `json:"Nickname"`
+	RoleName    string        `json:"RoleName,omitempty"`
+	ArtistID    int           `json:"ArtistID,omitempty"`
+	CircleID    string        `json:"CircleID,omitempty"`
+	VipLevel    int           `json:"VipLevel"`
+	CloudBean   int           `json:"CloudBean"`
+	DailyTasks  int           `json:"DailyTasks"`
+	WeeklyTasks int           `json:"WeeklyTasks"`
+	Targets     []TargetCheck `json:"Targets"`
+	Problems    []string      `json:"Problems,omitempty"`
+	Warnings    []string      `json:"Warnings,omitempty"`
+}
+
+// Healthy 账号是否没有任何问题
+func (h *AccountHealth) Healthy() bool {
+	return len(h.Problems) == 0
+}
+
+// problem 记录一个问题
+func (h *AccountHealth) problem(format string, a ...interface{}) {
+	h.Problems = append(h.Problems, fmt.Sprintf(format, a...))
+}
+
+// runCheckCommand 检查所有账号的登录状态、音乐人身份、云豆及配置的目标是否可用, 有账号异常时返回非 0
+func runCheckCommand(args []string) int {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	user := fs.Int("user", -1, "Only check this user (index in config Users)")
+	asJSON := fs.Bool("json", false, "Print results as JSON")
+	_ = fs.Parse(args)
+
+	if *user >= len(config.Users) {
+		fmt.Fprintf(os.Stderr, "User[%d] 不存在\n", *user)
+		return 2
+	}
+	var results []*AccountHealth
+	for i := range config.Users {
+		if *user >= 0 && i != *user {
+			continue
+		}
+		processingUser = i
+		results = append(results, checkAccount(i))
+	}
+
+	if *asJSON {
+		out, _ := json.MarshalIndent(results, "", "  ")
+		fmt.Println(string(out))
+	} else {
+		printHealth(results)
+	}
+	for _, h := range results {
+		if !h.Healthy() {
+			return 1
+		}
+	}
+	return 0
+}
+
+// checkAccount 检查单个账号
+func checkAccount(user int) *AccountHealth {
+	health := &AccountHealth{User: user}
+	data := accountRequestData(user)
+	loginStatus, err := api.GetLoginStatus(data)
+	if err != nil {
+		health.problem("获取登录状态失败: %v", err)
+		return health
+	}
+	if loginStatus.Profile.UserId == 0 {
+		health.problem("Cookie 已失效")
+		return health
+	}
+	health.UserID = loginStatus.Account.Id
+	health.Nickname = loginStatus.Profile.Nickname
+
+	userDetail, err := api.GetUserDetail(data, health.UserID)
+	if err != nil {
+		health.problem("获取用户详情失败: %v", err)
+	} else {
+		health.RoleName = userDetail.CurrentExpert.RoleName
+		health.ArtistID = userDetail.Profile.ArtistId
+	}
+	vipInfo, err := api.GetVipInfo(data)
+	if err != nil {
+		health.problem("获取会员信息失败: %v", err)
+	} else {
+		health.VipLevel = vipInfo.Data.RedVipLevel
+	}
+	cloudBean, err := api.GetCloudbeanNum(data)
+	if err != nil {
+		health.problem("获取云豆数失败: %v", err)
+	} else {
+		health.CloudBean = cloudBean.Data.CloudBean
+	}
+	if strings.Contains(health.RoleName, "网易音乐人") {
+		checkMusician(health, data)
+		checkTargets(health, data) // 配置的目标只在音乐人任务中使用
+	}
+	return health
+}
+
+// checkMusician 检查音乐人的云圈及任务列表
+func checkMusician(health *AccountHealth, data utils.RequestData) {
+	circleID = ""
+	artistDetail, err := api.GetArtistHomepage(data, int64(health.ArtistID))
+	if err != nil {
+		health.problem("获取艺人主页失败: %v", err)
+	} else {
+		parseCircleID(artistDetail)
+		health.CircleID = circleID
+	}
+	dailyTasks, err := api.GetMusicianDailyTasks(data)
+	if err != nil {
+		health.problem("获取音乐人每日任务失败: %v", err)
+	} else if dailyTasks.Code != 200 {
+		health.problem("获取音乐人每日任务失败: %s", dailyTasks.Message)
+	} else {
+		health.DailyTasks = len(dailyTasks.Data.List)
+	}
+	weeklyTasks, err := api.GetMusicianWeeklyTasks(data)
+	if err != nil {
+		health.problem("获取音乐人每周任务失败: %v", err)
+	} else if weeklyTasks.Code != 200 {
+		health.problem("获取音乐人每周任务失败: %s", weeklyTasks.Message)
+	} else {
+		health.WeeklyTasks = len(weeklyTasks.Data.List)
+	}
+}
+
+// checkTargets 检查配置中该账号的音乐人任务使用的歌曲、评论及私信对象是否存在且可访问
+func checkTargets(health *AccountHealth, data utils.RequestData) {
+	user := health.User
+	if config.MusicShareConfig.MySongID != 0 {
+		health.Targets = append(health.Targets, checkSongs(data, "分享", config.MusicShareConfig.MySongID)...)
+	}
+	if len(config.SendMlogConfig.MusicIDs) != 0 {
+		health.Targets = append(health.Targets, checkSongs(data, "Mlog", config.SendMlogConfig.MusicIDs...)...)
+	}
+	if user < len(config.CommentConfig.RepliedComment) {
+		c := config.CommentConfig.RepliedComment[user]
+		health.Targets = append(health.Targets, checkSongs(data, "评论", c.MusicID)...)
+		health.Targets = append(health.Targets, checkComment(data, c.MusicID, c.CommentID))
+	} else {
+		health.problem("CommentConfig.RepliedComment 中缺少 User[%d] 的配置", user)
+	}
+	if user < len(config.SendMsgConfig.UserID) {
+		for _, id := range config.SendMsgConfig.UserID[user] {
+			health.Targets = append(health.Targets, checkUser(data, id))
+		}
+	} else {
+		health.problem("SendMsgConfig.UserID 中缺少 User[%d] 的配置", user)
+	}
+	for _, t := range health.Targets {
+		if !t.OK {
+			health.problem("%s使用的%s %d 不可用: %s", t.Usage, targetNames[t.Type], t.ID, t.Error)
+		} else if t.Warning != "" {
+			health.Warnings = append(health.Warnings, fmt.Sprintf("%s使用的%s %d: %s", t.Usage, targetNames[t.Type], t.ID, t.Warning))
+		}
+	}
+}
+
+// checkSongs 检查歌曲是否存在
+func checkSongs(data utils.RequestData, usage string, ids ...int) []TargetCheck {
+	var checks []TargetCheck
+	detail, err := api.GetSongDetail(data, ids)
+	found := map[int]string{}
+	for _, song := range detail.Songs {
+		var artists []string
+		for _, ar := range song.Ar {
+			artists = append(artists, ar.Name)
+		}
+		found[song.Id] = fmt.Sprintf("%s - %s", song.Name, strings.Join(artists, "/"))
+	}
+	for _, id := range ids {
+		check := TargetCheck{Type: TargetSong, ID: id, Usage: usage, Name: found[id]}
+		switch {
+		case err != nil:
+			check.Error = err.Error()
+		case check.Name == "":
+			check.Error = "歌曲不存在"
+		default:
+			check.OK = true
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// checkComment 检查评论所在的评论区是否可访问, 并在前 checkCommentPages 页中查找该评论.
+// 评论区超过查找范围时未找到该评论只作为警告, 不影响检查结果
+func checkComment(data utils.RequestData, musicID, commentID int) TargetCheck {
+	check := TargetCheck{Type: TargetComment, ID: commentID, Usage: "评论"}
+	for page := 1; page <= checkCommentPages; page++ {
+		comments, err := api.GetComment(data, api.GetCommentConfig{ResType: api.ResTypeMusic, ResID: musicID, PageNo: page, PageSize: checkCommentPageSize})
+		if err != nil {
+			check.Error = err.Error()
+			return check
+		}
+		if comments.Code != 200 {
+			check.Error = fmt.Sprintf("获取歌曲 %d 的评论失败, 代码: %d", musicID, comments.Code)
+			return check
+		}
+		for _, c := range comments.Data.Comments {
+			if c.CommentId == int64(commentID) {
+				check.OK = true
+				check.Name = c.Content
+				return check
+			}
+		}
+		if len(comments.Data.Comments) < checkCommentPageSize {
+			check.Error = "评论不存在"
+			return check
+		}
+	}
+	check.OK = true
+	check.Warning = fmt.Sprintf("未在前 %d 条评论中找到, 无法确认是否存在", checkCommentPages*checkCommentPageSize)
+	return check
+}
+
+// checkUser 检查私信对象是否存在
+func checkUser(data utils.RequestData, userID int) TargetCheck {
+	check := TargetCheck{Type: TargetUser, ID: userID, Usage: "私信"}
+	detail, err := api.GetUserDetail(data, userID)
+	switch {
+	case err != nil:
+		check.Error = err.Error()
+	case detail.Code != 200 || detail.Profile.UserId != userID:
+		check.Error = fmt.Sprintf("用户不存在, 代码: %d", detail.Code)
+	default:
+		check.OK = true
+		check.Name = detail.Profile.Nickname
+	}
+	return check
+}
+
+// printHealth 以表格输出检查结果, 并在表格后列出各账号的问题
+func printHealth(results []*AccountHealth) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "USER\tNICKNAME\tUSER ID\tROLE\tARTIST ID\tCIRCLE ID\tVIP\tBEAN\tTASKS\tTARGETS\tSTATUS")
+	for _, h := range results {
+		var ok int
+		for _, t := range h.Targets {
+			if t.OK {
+				ok++
+			}
+		}
+		tasks := "-"
+		if h.DailyTasks+h.WeeklyTasks != 0 {
+			tasks = fmt.Sprintf("%d/%d", h.DailyTasks, h.WeeklyTasks)
+		}
+		state := "OK"
+		if !h.Healthy() {
+			state = "FAILED"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%d\t%s\t%s\t%s\t%d\t%d\t%s\t%d/%d\t%s\n",
+			h.User, dashIfEmpty(h.Nickname), h.UserID, dashIfEmpty(h.RoleName), dashIfEmpty(itoaIfNotZero(h.ArtistID)),
+			dashIfEmpty(h.CircleID), h.VipLevel, h.CloudBean, tasks, ok, len(h.Targets), state)
+	}
+	_ = w.Flush()
+	for _, h := range results {
+		for _, p := range h.Problems {
+			fmt.Printf("User[%d] %s\n", h.User, p)
+		}
+		for _, w := range h.Warnings {
+			fmt.Printf("User[%d] 警告: %s\n", h.User, w)
+		}
+	}
+}
+
+func dashIfEmpty(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func itoaIfNotZero(i int) string {
+	if i == 0 {
+		return ""
+	}
+	return strconv.Itoa(i)
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/XiaoMengXinX/Music163Api-Go/api"
+	"github.com/XiaoMengXinX/Music163Api-Go/types"
+	"github.com/XiaoMengXinX/Music163Api-Go/utils"
+)
+
+// 此文件封装所有会修改账号状态的 API, 每次调用都会写入审计日志
+
+// responseMessage 从 API 返回的原始内容中读取错误原因, 用于返回类型中没有 Message 字段的 API
+func responseMessage(raw string) string {
+	var result struct {
+		Message string `json:"message"`
+		Msg     string `json:"msg"`
+	}
+	_ = json.Unmarshal([]byte(raw), &result)
+	if result.Message != "" {
+		return result.Message
+	}
+	return result.Msg
+}
+
+func newAuditRecord(userData types.LoginStatusData, action string, err error) AuditRecord {
+	record := AuditRecord{
+		AccountID: userData.Account.Id,
+		Nickname:  userData.Profile.Nickname,
+		Action:    action,
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	return record
+}
+
+func userSign(userData types.LoginStatusData, data utils.RequestData, signType int) (types.UserSignData, error) {
+	result, err := api.UserSign(data, signType)
+	record := newAuditRecord(userData, AuditSign, err)
+	record.Targets = map[string]int{"SignType": signType}
+	record.Code, record.Message = result.Code, result.Msg
+	writeAudit(record)
+	return result, err
+}
+
+func musicianSign(userData types.LoginStatusData, data utils.RequestData) (types.MusicianSignData, error) {
+	result, err := api.MusicianSign(data)
+	record := newAuditRecord(userData, AuditMusicianSign, err)
+	record.Code, record.Message = result.Code, result.Message
+	writeAudit(record)
+	return result, err
+}
+
+func songShare(userData types.LoginStatusData, data utils.RequestData, songID int) (types.SongShareData, error) {
+	result, err := api.SongShare(data, songID)
+	record := newAuditRecord(userData, AuditShare, err)
+	record.Targets = map[string]int{"SongID": songID}
+	record.Code, record.Message = result.Code, result.Message
+	writeAudit(record)
+	return result, err
+}
+
+func shareResource(userData types.LoginStatusData, data utils.RequestData, resourceID int, resourceType, msg string) (types.SendEventData, error) {
+	result, err := api.ShareResource(data, resourceID, resourceType, msg)
+	record := newAuditRecord(userData, AuditEventSend, err)
+	record.Targets = map[string]int{"SongID": resourceID, "EventID": result.Event.Id}
+	record.Content = msg
+	record.Code, record.Message = result.Code, result.Message
+	writeAudit(record)
+	return result, err
+}
+
+func sendEvent(userData types.LoginStatusData, data utils.RequestData, text string) (types.SendEventData, error) {
+	result, err := api.SendEvent(data, text, []string{})
+	record := newAuditRecord(userData, AuditEventSend, err)
+	record.Targets = map[string]int{"EventID": result.Event.Id}
+	record.Content = text
+	record.Code, record.Message = result.Code, result.Message
+	writeAudit(record)
+	return result, err
+}
+
+func delEvent(userData types.LoginStatusData, data utils.RequestData, eventID int, action string) (types.DelEventData, error) {
+	result, err := api.DelEvent(data, eventID)
+	record := newAuditRecord(userData, action, err)
+	record.Targets = map[string]int{"EventID": eventID}
+	record.Code, record.Message = result.Code, result.Message
+	writeAudit(record)
+	return result, err
+}
+
+func addComment(userData types.LoginStatusData, data utils.RequestData, commentConfig api.CommentConfig) (types.AddCommentData, error) {
+	result, err := api.AddComment(data, commentConfig)
+	record := newAuditRecord(userData, AuditCommentAdd, err)
+	record.Targets = map[string]int{"ResID": commentConfig.ResID, "CommentID": result.Comment.CommentId}
+	record.Content = commentConfig.Content
+	record.Code = result.Code
+	writeAudit(record)
+	return result, err
+}
+
+func replyComment(userData types.LoginStatusData, data utils.RequestData, commentConfig api.CommentConfig) (types.ReplyCommentData, error) {
+	result, err := api.ReplyComment(data, commentConfig)
+	record := newAuditRecord(userData, AuditCommentReply, err)
+	record.Targets = map[string]int{"ResID": commentConfig.ResID, "ReplyTo": commentConfig.CommentID, "CommentID": result.Comment.CommentId}
+	record.Content = commentConfig.Content
+	record.Code = result.Code
+	writeAudit(record)
+	return result, err
+}
+
+func delComment(userData types.LoginStatusData, data utils.RequestData, commentConfig api.CommentConfig) (types.DelCommentData, error) {
+	result, err := api.DelComment(data, commentConfig)
+	record := newAuditRecord(userData, AuditCommentDelete, err)
+	record.Targets = map[string]int{"ResID": commentConfig.ResID, "CommentID": commentConfig.CommentID}
+	record.Code, record.Message = result.Code, responseMessage(result.RawJson)
+	writeAudit(record)
+	return result, err
+}
+
+func sendTextMsg(userData types.LoginStatusData, data utils.RequestData, userIDs []int, text string) (types.SendMsgData, error) {
+	result, err := api.SendTextMsg(data, userIDs, text)
+	record := newAuditRecord(userData, AuditMessage, err)
+	record.Targets = map[string]int{}
+	if len(userIDs) != 0 {
+		record.Targets["UserID"] = userIDs[0]
+	}
+	record.Content = text
+	record.Code = result.Code
+	writeAudit(record)
+	return result, err
+}
+
+func sendPicMlog(userData types.LoginStatusData, data utils.RequestData, text string, songID int, picPath []string) (types.SendMlogData, error) {
+	err := resolveUploadNode(data)
+	var result types.SendMlogData
+	if err == nil {
+		result, err = api.SendPicMlog(data, text, songID, picPath)
+	}
+	record := newAuditRecord(userData, AuditMlogSend, err)
+	record.Targets = map[string]int{"SongID": songID, "EventID": result.Data.Event.Id}
+	record.Content = text
+	record.Code, record.Message = result.Code, result.Message
+	writeAudit(record)
+	return result, err
+}
+
+func obtainCloudbean(userData types.LoginStatusData, data utils.RequestData, userMissionID, period int) (types.ObtainCloudebeanData, error) {
+	result, err := api.ObtainCloudbean(data, userMissionID, period)
+	record := newAuditRecord(userData, AuditBeanClaim, err)
+	record.Targets = map[string]int{"UserMissionID": userMissionID, "Period": period}
+	record.Code, record.Message = result.Code, result.Message
+	writeAudit(record)
+	return result, err
+}
+
+func vipTaskRewardAll(userData types.LoginStatusData, data utils.RequestData) (types.VipTaskRewardData, error) {
+	result, err := api.VipTaskRewardAll(data)
+	record := newAuditRecord(userData, AuditVipReward, err)
+	record.Code, record.Message = result.Code, result.Message
+	writeAudit(record)
+	return result, err
+}
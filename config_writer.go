@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/XiaoMengXinX/Fuck163MusicTasks/v2/configfile"
+)
+
+// saveUserCookies 将 Users[user].Cookies 写入配置文件, 只修改对应的 Cookies 部分 (不存在时添加), 保留配置文件的其他内容及格式.
+// 原文件备份为 file.<时间>.bak
+func saveUserCookies(file string, user int, cookies []*http.Cookie) error {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	users, err := configfile.ObjectValue(data, 0, "Users")
+	if err != nil {
+		return err
+	}
+	elements, err := configfile.ArrayElements(data, users.Start)
+	if err != nil {
+		return fmt.Errorf("解析 Users 失败: %v", err)
+	}
+	if user >= len(elements) {
+		return fmt.Errorf("配置文件中不存在 User[%d]", user)
+	}
+	element := elements[user]
+	unit := strings.TrimPrefix(configfile.IndentOf(data, element.Start), configfile.IndentOf(data, users.Start))
+	if unit == "" {
+		unit = "  "
+	}
+	newData, err := configfile.SetObjectValue(data, element, "Cookies", configfile.Cookies(cookies), unit)
+	if err != nil {
+		return err
+	}
+	if !json.Valid(newData) {
+		return fmt.Errorf("生成的配置文件格式错误, 未修改配置文件")
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	if _, err = configfile.WriteBackup(file, data, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("备份配置文件失败: %v", err)
+	}
+	tmpFile := file + ".tmp"
+	err = ioutil.WriteFile(tmpFile, newData, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, file)
+}
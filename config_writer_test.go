@@ -0,0 +1,137 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveUserCookies(t *testing.T) {
+	const config = `{
+    "Users": [
+        {
+            "Cookies": []
+        },
+        {
+            "Device": {"Profile": "android"},
+            "Cookies": [{"Name": "MUSIC_U", "Value": "old"}], "Proxy": ""
+        },
+        {
+            "Proxy": "socks5://127.0.0.1:1080"
+        },
+        {}
+    ]
+}`
+	tests := []struct {
+		name    string
+		user    int
+		want    string
+		wantErr bool
+	}{
+		{"替换已有的 Cookies", 1, `{
+    "Users": [
+        {
+            "Cookies": []
+        },
+        {
+            "Device": {"Profile": "android"},
+            "Cookies": [
+                {
+                    "Name": "MUSIC_U",
+                    "Value": "new"
+                }
+            ], "Proxy": ""
+        },
+        {
+            "Proxy": "socks5://127.0.0.1:1080"
+        },
+        {}
+    ]
+}`, false},
+		{"没有 Cookies 时添加", 2, `{
+    "Users": [
+        {
+            "Cookies": []
+        },
+        {
+            "Device": {"Profile": "android"},
+            "Cookies": [{"Name": "MUSIC_U", "Value": "old"}], "Proxy": ""
+        },
+        {
+            "Cookies": [
+                {
+                    "Name": "MUSIC_U",
+                    "Value": "new"
+                }
+            ],
+            "Proxy": "socks5://127.0.0.1:1080"
+        },
+        {}
+    ]
+}`, false},
+		{"空的账号", 3, `{
+    "Users": [
+        {
+            "Cookies": []
+        },
+        {
+            "Device": {"Profile": "android"},
+            "Cookies": [{"Name": "MUSIC_U", "Value": "old"}], "Proxy": ""
+        },
+        {
+            "Proxy": "socks5://127.0.0.1:1080"
+        },
+        {
+            "Cookies": [
+                {
+                    "Name": "MUSIC_U",
+                    "Value": "new"
+                }
+            ]
+        }
+    ]
+}`, false},
+		{"账号不存在", 4, config, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "config.json")
+			if err := ioutil.WriteFile(file, []byte(config), 0600); err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 2; i++ {
+				err := saveUserCookies(file, tt.user, []*http.Cookie{{Name: "MUSIC_U", Value: "new"}})
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("saveUserCookies() error = %v, wantErr %v", err, tt.wantErr)
+				}
+			}
+			got, _ := ioutil.ReadFile(file)
+			if string(got) != tt.want {
+				t.Errorf("配置文件 =\n%s\nwant\n%s", got, tt.want)
+			}
+			backups, _ := filepath.Glob(file + ".*.bak")
+			if tt.wantErr {
+				if len(backups) != 0 {
+					t.Errorf("写入失败时不应备份")
+				}
+				return
+			}
+			if len(backups) != 2 {
+				t.Fatalf("两次写入应有 2 个备份, 实际 %d 个", len(backups))
+			}
+			var original bool
+			for _, b := range backups {
+				data, _ := ioutil.ReadFile(b)
+				original = original || string(data) == config
+			}
+			if !original {
+				t.Errorf("原配置文件的备份被覆盖")
+			}
+			if strings.Contains(string(got), `"Value": "old"`) && tt.user == 1 {
+				t.Errorf("未替换原有的 Cookie")
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package configfile
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// WriteBackup 将原配置文件备份为 file.<时间>.bak, 同名备份已存在时添加序号, 不会覆盖已有的备份
+func WriteBackup(file string, data []byte, perm os.FileMode) (string, error) {
+	base := fmt.Sprintf("%s.%s", file, time.Now().Format("20060102-150405.000"))
+	backup := base + ".bak"
+	for i := 1; ; i++ {
+		f, err := os.OpenFile(backup, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+		if os.IsExist(err) {
+			backup = fmt.Sprintf("%s.%d.bak", base, i)
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		_, err = f.Write(data)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		return backup, err
+	}
+}
@@ -0,0 +1,30 @@
+package configfile
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteBackup(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+	seen := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		backup, err := WriteBackup(file, []byte{byte('0' + i)}, 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seen[backup] {
+			t.Fatalf("备份 %s 被覆盖", backup)
+		}
+		seen[backup] = true
+		if !strings.HasPrefix(backup, file+".") || !strings.HasSuffix(backup, ".bak") {
+			t.Errorf("备份文件名 %s 格式错误", backup)
+		}
+		data, _ := ioutil.ReadFile(backup)
+		if string(data) != string([]byte{byte('0' + i)}) {
+			t.Errorf("备份内容 = %q", data)
+		}
+	}
+}
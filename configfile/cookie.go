@@ -0,0 +1,47 @@
+// Package configfile 主程序与 quickLogin 共用的配置文件读写工具: Cookie 的格式转换, 以及在保留原有格式的前提下修改 JSON 配置文件中的某一部分
+package configfile
+
+import (
+	"net/http"
+	"time"
+)
+
+// Cookie 写入配置文件的 Cookie, 字段与 http.Cookie 的 JSON 格式一致
+type Cookie struct {
+	Name    string     `json:"Name"`
+	Value   string     `json:"Value"`
+	Path    string     `json:"Path,omitempty"`
+	Domain  string     `json:"Domain,omitempty"`
+	Expires *time.Time `json:"Expires,omitempty"`
+}
+
+// Cookies 将 http.Cookie 转换为写入配置文件的格式
+func Cookies(cookies []*http.Cookie) []Cookie {
+	var result []Cookie
+	for _, c := range cookies {
+		cookie := Cookie{Name: c.Name, Value: c.Value, Path: c.Path, Domain: c.Domain}
+		if !c.Expires.IsZero() {
+			expires := c.Expires.Local()
+			cookie.Expires = &expires
+		}
+		result = append(result, cookie)
+	}
+	return result
+}
+
+// ParseCookies 解析返回的所有 Set-Cookie, 忽略已删除的 Cookie, 同名 Cookie 只保留第一个
+func ParseCookies(header http.Header) []*http.Cookie {
+	var cookies []*http.Cookie
+	seen := map[string]bool{}
+	for _, c := range (&http.Response{Header: header}).Cookies() {
+		if c.Value == "" || c.MaxAge < 0 || seen[c.Name] {
+			continue
+		}
+		if c.MaxAge > 0 && c.Expires.IsZero() {
+			c.Expires = time.Now().Add(time.Duration(c.MaxAge) * time.Second)
+		}
+		seen[c.Name] = true
+		cookies = append(cookies, c)
+	}
+	return cookies
+}
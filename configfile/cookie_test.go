@@ -0,0 +1,55 @@
+package configfile
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseCookies(t *testing.T) {
+	header := http.Header{}
+	header.Add("Set-Cookie", "MUSIC_U=new; Max-Age=3600; Path=/")
+	header.Add("Set-Cookie", "MUSIC_U=second; Path=/")
+	header.Add("Set-Cookie", "__csrf=abc; Expires=Wed, 21 Oct 2037 07:28:00 GMT; Path=/")
+	header.Add("Set-Cookie", "MUSIC_A=; Path=/")
+	header.Add("Set-Cookie", "NMTID=x; Max-Age=-1")
+
+	cookies := ParseCookies(header)
+	tests := []struct {
+		name, value string
+	}{
+		{"MUSIC_U", "new"},
+		{"__csrf", "abc"},
+	}
+	if len(cookies) != len(tests) {
+		t.Fatalf("ParseCookies() 返回 %d 个 Cookie, want %d", len(cookies), len(tests))
+	}
+	for i, tt := range tests {
+		if cookies[i].Name != tt.name || cookies[i].Value != tt.value {
+			t.Errorf("cookies[%d] = %s=%s, want %s=%s", i, cookies[i].Name, cookies[i].Value, tt.name, tt.value)
+		}
+	}
+	if d := time.Until(cookies[0].Expires); d < 59*time.Minute || d > time.Hour {
+		t.Errorf("Max-Age 应转换为 Expires, 剩余 %s", d)
+	}
+	if cookies[1].Expires.Year() != 2037 {
+		t.Errorf("应保留 Expires: %s", cookies[1].Expires)
+	}
+}
+
+func TestCookies(t *testing.T) {
+	expires := time.Date(2037, 10, 21, 7, 28, 0, 0, time.UTC)
+	got := Cookies([]*http.Cookie{
+		{Name: "MUSIC_U", Value: "abc", Path: "/", Expires: expires},
+		{Name: "__csrf", Value: "x", Domain: ".music.163.com"},
+	})
+	if len(got) != 2 {
+		t.Fatalf("Cookies() 返回 %d 个, want 2", len(got))
+	}
+	if got[0].Expires == nil || !got[0].Expires.Equal(expires) {
+		t.Errorf("Expires = %v, want %s", got[0].Expires, expires)
+	}
+	if got[1].Expires != nil || got[1].Domain != ".music.163.com" {
+		t.Errorf("cookies[1] = %+v", got[1])
+	}
+}
@@ -0,0 +1,112 @@
+package configfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Span 配置文件中一段 JSON 值的起止位置
+type Span struct {
+	Start, End int
+}
+
+// ObjectValue 返回 offset 处的 JSON 对象中 key 对应值的位置
+func ObjectValue(data []byte, offset int, key string) (Span, error) {
+	dec := json.NewDecoder(bytes.NewReader(data[offset:]))
+	tok, err := dec.Token()
+	if err != nil {
+		return Span{}, err
+	}
+	if tok != json.Delim('{') {
+		return Span{}, fmt.Errorf("应为 JSON 对象")
+	}
+	for dec.More() {
+		tok, err = dec.Token()
+		if err != nil {
+			return Span{}, err
+		}
+		var raw json.RawMessage
+		if err = dec.Decode(&raw); err != nil {
+			return Span{}, err
+		}
+		if tok == key {
+			end := offset + int(dec.InputOffset())
+			return Span{end - len(raw), end}, nil
+		}
+	}
+	return Span{}, fmt.Errorf("未找到 \"%s\" 字段", key)
+}
+
+// ArrayElements 返回 offset 处的 JSON 数组中各元素的位置
+func ArrayElements(data []byte, offset int) ([]Span, error) {
+	dec := json.NewDecoder(bytes.NewReader(data[offset:]))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if tok != json.Delim('[') {
+		return nil, fmt.Errorf("应为 JSON 数组")
+	}
+	var elements []Span
+	for dec.More() {
+		var raw json.RawMessage
+		if err = dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		end := offset + int(dec.InputOffset())
+		elements = append(elements, Span{end - len(raw), end})
+	}
+	return elements, nil
+}
+
+// SetObjectValue 将 object 位置的 JSON 对象中 key 的值设置为 v, 对象中没有该字段时添加为第一个字段.
+// 新的值按所在行的缩进格式化, unit 为每一级的缩进, 返回新的内容, 不修改 data
+func SetObjectValue(data []byte, object Span, key string, v interface{}, unit string) ([]byte, error) {
+	if target, err := ObjectValue(data, object.Start, key); err == nil {
+		value, err := json.MarshalIndent(v, IndentOf(data, target.Start), unit)
+		if err != nil {
+			return nil, err
+		}
+		return Replace(data, target, value), nil
+	}
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data[object.Start:object.End], &members); err != nil {
+		return nil, err
+	}
+	indent := IndentOf(data, object.Start)
+	value, err := json.MarshalIndent(v, indent+unit, unit)
+	if err != nil {
+		return nil, err
+	}
+	name, _ := json.Marshal(key)
+	member := "\n" + indent + unit + string(name) + ": " + string(value)
+	if len(members) != 0 {
+		member += ","
+	} else {
+		member += "\n" + indent
+	}
+	pos := object.Start + 1
+	if len(members) == 0 {
+		return Replace(data, Span{pos, object.End - 1}, []byte(member)), nil // 去掉空对象中的空白
+	}
+	return Replace(data, Span{pos, pos}, []byte(member)), nil
+}
+
+// IndentOf 返回 pos 所在行的缩进
+func IndentOf(data []byte, pos int) string {
+	start := bytes.LastIndexByte(data[:pos], '\n') + 1
+	end := start
+	for end < len(data) && (data[end] == ' ' || data[end] == '\t') {
+		end++
+	}
+	return string(data[start:end])
+}
+
+// Replace 将 s 位置的内容替换为 value, 返回新的内容, 不修改 data
+func Replace(data []byte, s Span, value []byte) []byte {
+	newData := make([]byte, 0, len(data)+len(value))
+	newData = append(newData, data[:s.Start]...)
+	newData = append(newData, value...)
+	return append(newData, data[s.End:]...)
+}
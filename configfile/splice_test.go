@@ -0,0 +1,156 @@
+package configfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestObjectValue(t *testing.T) {
+	data := []byte(`{"Users": [{"Content": "x"}], "Content": ["a", "b"]}`)
+	tests := []struct {
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{"Content", `["a", "b"]`, false},
+		{"Missing", "", true},
+	}
+	for _, tt := range tests {
+		s, err := ObjectValue(data, 0, tt.key)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("ObjectValue(%q) error = %v, wantErr %v", tt.key, err, tt.wantErr)
+		}
+		if !tt.wantErr && string(data[s.Start:s.End]) != tt.want {
+			t.Errorf("ObjectValue(%q) = %q, want %q", tt.key, data[s.Start:s.End], tt.want)
+		}
+	}
+	if _, err := ObjectValue([]byte(`[1]`), 0, "a"); err == nil {
+		t.Errorf("非 JSON 对象应返回错误")
+	}
+}
+
+func TestArrayElements(t *testing.T) {
+	tests := []struct {
+		data    string
+		want    []string
+		wantErr bool
+	}{
+		{`[]`, nil, false},
+		{`[1, "a" , {"b": [2]}]`, []string{`1`, `"a"`, `{"b": [2]}`}, false},
+		{`{"a": 1}`, nil, true},
+	}
+	for _, tt := range tests {
+		elements, err := ArrayElements([]byte(tt.data), 0)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("ArrayElements(%s) error = %v, wantErr %v", tt.data, err, tt.wantErr)
+		}
+		if len(elements) != len(tt.want) {
+			t.Fatalf("ArrayElements(%s) = %d 个元素, want %d", tt.data, len(elements), len(tt.want))
+		}
+		for i, e := range elements {
+			if got := tt.data[e.Start:e.End]; got != tt.want[i] {
+				t.Errorf("ArrayElements(%s)[%d] = %q, want %q", tt.data, i, got, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestIndentOf(t *testing.T) {
+	data := []byte("{\n  \"a\": {\n\t\t\"b\": 1\n  }\n}")
+	tests := []struct {
+		sub  string
+		want string
+	}{
+		{"{", ""},
+		{`"a"`, "  "},
+		{`"b"`, "\t\t"},
+	}
+	for _, tt := range tests {
+		pos := bytes.Index(data, []byte(tt.sub))
+		if got := IndentOf(data, pos); got != tt.want {
+			t.Errorf("IndentOf(%q) = %q, want %q", tt.sub, got, tt.want)
+		}
+	}
+}
+
+func TestSpliceCookies(t *testing.T) {
+	data := []byte(`{
+  "Users": [
+    {
+      "Cookies": [{"Name": "MUSIC_U", "Value": "old"}],
+      "Proxy": ""
+    },
+    {
+      "Proxy": "socks5://127.0.0.1:1080",
+      "Cookies": []
+    }
+  ],
+  "Content": ["a", "b"]
+}`)
+	users, err := ObjectValue(data, 0, "Users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	elements, err := ArrayElements(data, users.Start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	target, err := ObjectValue(data, elements[1].Start, "Cookies")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newData := Replace(data, target, []byte(`[{"Name": "MUSIC_U", "Value": "new"}]`))
+	if !json.Valid(newData) {
+		t.Fatalf("替换后的内容不是有效的 JSON:\n%s", newData)
+	}
+	want := `{
+  "Users": [
+    {
+      "Cookies": [{"Name": "MUSIC_U", "Value": "old"}],
+      "Proxy": ""
+    },
+    {
+      "Proxy": "socks5://127.0.0.1:1080",
+      "Cookies": [{"Name": "MUSIC_U", "Value": "new"}]
+    }
+  ],
+  "Content": ["a", "b"]
+}`
+	if string(newData) != want {
+		t.Errorf("Replace() =\n%s\nwant\n%s", newData, want)
+	}
+	if string(data[target.Start:target.End]) != "[]" {
+		t.Errorf("Replace 不应修改原内容")
+	}
+}
+
+func TestSetObjectValue(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"替换已有字段", "{\n  \"a\": 1,\n  \"b\": 2\n}", "{\n  \"a\": [\n    \"x\"\n  ],\n  \"b\": 2\n}"},
+		{"添加为第一个字段", "{\n  \"b\": 2\n}", "{\n  \"a\": [\n    \"x\"\n  ],\n  \"b\": 2\n}"},
+		{"空对象", "{ }", "{\n  \"a\": [\n    \"x\"\n  ]\n}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := []byte(tt.data)
+			got, err := SetObjectValue(data, Span{0, len(data)}, "a", []string{"x"}, "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("SetObjectValue() =\n%s\nwant\n%s", got, tt.want)
+			}
+			if !json.Valid(got) {
+				t.Errorf("结果不是有效的 JSON")
+			}
+		})
+	}
+	if _, err := SetObjectValue([]byte(`[1]`), Span{0, 3}, "a", 1, "  "); err == nil {
+		t.Errorf("非 JSON 对象应返回错误")
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/XiaoMengXinX/Music163Api-Go/api"
+	"github.com/XiaoMengXinX/Music163Api-Go/types"
+	"github.com/XiaoMengXinX/Music163Api-Go/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// weekdays 中文星期
+var weekdays = []string{"星期日", "星期一", "星期二", "星期三", "星期四", "星期五", "星期六"}
+
+// emojis 模板函数 emoji 随机选择的表情
+var emojis = []string{"😀", "😄", "😆", "😊", "😉", "😍", "🥰", "😘", "😎", "🤗", "🤩", "🥳", "😌", "🙂", "👍", "👏", "🙌", "💪", "✨", "🎉", "🎶", "🎵", "🎧", "🎸", "🎹", "❤️", "💖", "🌟", "🌈", "☀️", "🌙", "🍀"}
+
+// contentFuncs 内容模板可用的函数
+var contentFuncs = template.FuncMap{
+	// date 按 Go 时间格式输出当前时间, 如 {{date "01月02日 15:04"}}
+	"date": func(layout string) string {
+		return time.Now().Format(layout)
+	},
+	// emoji 随机表情
+	"emoji": func() string {
+		return emojis[rand.Intn(len(emojis))]
+	},
+	// pick 从参数中随机选择一个, 如 {{pick "早安" "你好" "嗨"}}
+	"pick": func(items ...string) string {
+		if len(items) == 0 {
+			return ""
+		}
+		return items[rand.Intn(len(items))]
+	},
+	// randInt 返回 [min, max] 之间的随机整数
+	"randInt": func(min, max int) int {
+		if max <= min {
+			return min
+		}
+		return min + rand.Intn(max-min+1)
+	},
+}
+
+// contentTemplates 解析后的 Content 模板
+var contentTemplates []*template.Template
+
+// parseContentTemplates 将 Content 中的每一项解析为 Go 模板
+func parseContentTemplates() error {
+	contentTemplates = nil
+	for i, text := range config.Content {
+		tmpl, err := template.New(fmt.Sprintf("Content[%d]", i)).Funcs(contentFuncs).Parse(text)
+		if err != nil {
+			return err
+		}
+		// 使用示例数据执行一次, 提前发现不存在的变量或函数参数错误
+		if err = tmpl.Execute(ioutil.Discard, &ContentData{Time: time.Now()}); err != nil {
+			return err
+		}
+		contentTemplates = append(contentTemplates, tmpl)
+	}
+	return nil
+}
+
+// ContentData 内容模板可用的变量, 歌曲信息在模板中用到时才会查询
+type ContentData struct {
+	Time     time.Time // 当前时间
+	Nickname string    // 账号昵称
+	UserID   int       // 账号用户 ID
+	SongID   int       // 目标歌曲 ID, 任务没有目标歌曲时为 MusicShareConfig.MySongID
+
+	data  utils.RequestData
+	songs map[int]*types.SongDetailData // 已查询的歌曲信息, 查询失败时为 nil
+}
+
+// Date 当前日期, 如 2021-08-24
+func (c *ContentData) Date() string {
+	return c.Time.Format("2006-01-02")
+}
+
+// Weekday 当前是星期几, 如 星期二
+func (c *ContentData) Weekday() string {
+	return weekdays[c.Time.Weekday()]
+}
+
+// Song 目标歌曲名
+func (c *ContentData) Song() string {
+	if song := c.songDetail(); song != nil {
+		return song.Name
+	}
+	return ""
+}
+
+// Artist 目标歌曲的歌手, 多个歌手以 "/" 分隔
+func (c *ContentData) Artist() string {
+	song := c.songDetail()
+	if song == nil {
+		return ""
+	}
+	var artists []string
+	for _, ar := range song.Ar {
+		artists = append(artists, ar.Name)
+	}
+	return strings.Join(artists, "/")
+}
+
+// songDetail 查询并缓存目标歌曲信息, 查询失败时返回 nil
+func (c *ContentData) songDetail() *types.SongDetailData {
+	if c.SongID == 0 {
+		return nil
+	}
+	if song, ok := c.songs[c.SongID]; ok {
+		return song
+	}
+	if c.songs == nil {
+		c.songs = map[int]*types.SongDetailData{}
+	}
+	c.songs[c.SongID] = nil
+	detail, err := api.GetSongDetail(c.data, []int{c.SongID})
+	if err != nil {
+		log.Warnf("[%s] 获取歌曲 %d 的信息失败: %v", c.Nickname, c.SongID, err)
+		return nil
+	}
+	if len(detail.Songs) == 0 {
+		log.Warnf("[%s] 歌曲 %d 不存在", c.Nickname, c.SongID)
+		return nil
+	}
+	c.songs[c.SongID] = &detail.Songs[0]
+	return c.songs[c.SongID]
+}
+
+// contentRenderer 为同一账号多次生成内容, 每首目标歌曲的信息只查询一次
+type contentRenderer struct {
+	userData types.LoginStatusData
+	data     utils.RequestData
+	songs    map[int]*types.SongDetailData
+}
+
+func newContentRenderer(userData types.LoginStatusData, data utils.RequestData) *contentRenderer {
+	return &contentRenderer{userData: userData, data: data, songs: map[int]*types.SongDetailData{}}
+}
+
+// renderContent 随机选择一项 Content 并填入当前账号及目标歌曲的信息
+func renderContent(userData types.LoginStatusData, data utils.RequestData, songID int) (string, error) {
+	return newContentRenderer(userData, data).render(songID)
+}
+
+// render 随机选择一项 Content 并填入当前账号及目标歌曲的信息
+func (r *contentRenderer) render(songID int) (string, error) {
+	userData := r.userData
+	if songID == 0 {
+		songID = config.MusicShareConfig.MySongID
+	}
+	content := &ContentData{
+		Time:     time.Now(),
+		Nickname: userData.Profile.Nickname,
+		UserID:   userData.Account.Id,
+		SongID:   songID,
+		data:     r.data,
+		songs:    r.songs,
+	}
+	if len(contentTemplates) == 0 {
+		return "", fmt.Errorf("[%s] 未设置 Content, 无法生成内容", userData.Profile.Nickname)
+	}
+	rand.Seed(time.Now().UnixNano())
+	tmpl := contentTemplates[rand.Intn(len(contentTemplates))]
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, content); err != nil {
+		return "", fmt.Errorf("[%s] 生成内容失败: %v", userData.Profile.Nickname, err)
+	}
+	text := strings.TrimSpace(buf.String())
+	if text == "" {
+		return "", fmt.Errorf("[%s] 生成的内容为空, 请检查 %s", userData.Profile.Nickname, tmpl.Name())
+	}
+	return text, nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/XiaoMengXinX/Music163Api-Go/types"
+	"github.com/XiaoMengXinX/Music163Api-Go/utils"
+)
+
+func TestContentFuncs(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		check func(string) bool
+	}{
+		{"date 按格式输出当前时间", `{{date "2006"}}`, func(s string) bool { return s == time.Now().Format("2006") }},
+		{"emoji 返回预设表情", `{{emoji}}`, func(s string) bool {
+			for _, e := range emojis {
+				if s == e {
+					return true
+				}
+			}
+			return false
+		}},
+		{"pick 无参数返回空", `{{pick}}`, func(s string) bool { return s == "" }},
+		{"pick 单个参数", `{{pick "早安"}}`, func(s string) bool { return s == "早安" }},
+		{"pick 多个参数", `{{pick "早安" "你好" "嗨"}}`, func(s string) bool { return s == "早安" || s == "你好" || s == "嗨" }},
+		{"randInt 上限不大于下限时返回下限", `{{randInt 5 3}}`, func(s string) bool { return s == "5" }},
+		{"randInt 上下限相等", `{{randInt 7 7}}`, func(s string) bool { return s == "7" }},
+		{"randInt 返回区间内的值", `{{randInt 1 3}}`, func(s string) bool { return s == "1" || s == "2" || s == "3" }},
+	}
+	old := config.Content
+	defer func() {
+		config.Content = old
+		_ = parseContentTemplates()
+	}()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Content = []string{tt.text}
+			if err := parseContentTemplates(); err != nil {
+				t.Fatalf("parseContentTemplates() error = %v", err)
+			}
+			for i := 0; i < 20; i++ {
+				got, err := renderContent(types.LoginStatusData{}, utils.RequestData{}, 0)
+				if tt.text == `{{pick}}` {
+					// 生成内容为空时返回错误
+					if err == nil {
+						t.Fatalf("renderContent() = %q, want error", got)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("renderContent() error = %v", err)
+				}
+				if !tt.check(got) {
+					t.Fatalf("renderContent() = %q", got)
+				}
+			}
+		})
+	}
+}
+
+func TestParseContentTemplates(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []string
+		wantErr bool
+	}{
+		{"普通文本", []string{"早上好"}, false},
+		{"使用变量和函数", []string{`{{.Nickname}} {{.Date}} {{.Weekday}} {{emoji}} {{randInt 1 9}}`}, false},
+		{"未闭合的模板", []string{"早上好 {{"}, true},
+		{"不存在的变量", []string{"{{.Unknown}}"}, true},
+		{"不存在的函数", []string{"{{unknown}}"}, true},
+		{"函数参数类型错误", []string{`{{randInt "a" 2}}`}, true},
+		{"任一项无效", []string{"早上好", "{{.Unknown}}"}, true},
+	}
+	old := config.Content
+	defer func() {
+		config.Content = old
+		_ = parseContentTemplates()
+	}()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Content = tt.content
+			err := parseContentTemplates()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseContentTemplates() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && len(contentTemplates) != len(tt.content) {
+				t.Fatalf("parsed %d templates, want %d", len(contentTemplates), len(tt.content))
+			}
+		})
+	}
+}
+
+func TestContentRendererCache(t *testing.T) {
+	var song types.SongDetailData
+	if err := json.Unmarshal([]byte(`{"name":"晴天","ar":[{"name":"周杰伦"}]}`), &song); err != nil {
+		t.Fatal(err)
+	}
+	old := config.Content
+	defer func() {
+		config.Content = old
+		_ = parseContentTemplates()
+	}()
+	config.Content = []string{"{{.Song}} - {{.Artist}}"}
+	if err := parseContentTemplates(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 缓存中已有的歌曲 (包括查询失败的) 不会再次请求接口
+	r := newContentRenderer(types.LoginStatusData{}, utils.RequestData{})
+	r.songs[1] = &song
+	r.songs[2] = nil
+	for i := 0; i < 3; i++ {
+		got, err := r.render(1)
+		if err != nil || got != "晴天 - 周杰伦" {
+			t.Fatalf("render(1) = %q, %v", got, err)
+		}
+		got, err = r.render(2)
+		if err != nil || got != "-" {
+			t.Fatalf("render(2) = %q, %v", got, err)
+		}
+	}
+	if len(r.songs) != 2 {
+		t.Fatalf("cached %d songs, want 2", len(r.songs))
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// AccountState 账号的持久化状态, 用于 Cookie 失效时提供最后已知的账号信息
+type AccountState struct {
+	CookieHash    string    `json:"CookieHash"`
+	UserID        int       `json:"UserID"`
+	Nickname      string    `json:"Nickname"`
+	ValidSince    time.Time `json:"ValidSince"`
+	LastValid     time.Time `json:"LastValid"`
+	AlertedCookie string    `json:"AlertedCookie,omitempty"`
+	CookieExpires time.Time `json:"CookieExpires,omitempty"`
+	LastRefresh   time.Time `json:"LastRefresh,omitempty"`
+	RefreshError  string    `json:"RefreshError,omitempty"`
+	WarnedCookie  string    `json:"WarnedCookie,omitempty"`
+	DeviceID      string    `json:"DeviceID,omitempty"` // 旧版本按账号序号保存的设备 ID, 仅用于迁移到 DeviceIDs
+}
+
+// AccountStates 各账号 (config.Users 中的序号) 的持久化状态
+type AccountStates struct {
+	sync.Mutex
+	File     string
+	Accounts map[string]*AccountState
+}
+
+var accountStates AccountStates
+
+// Load 从文件读取账号状态
+func (s *AccountStates) Load(file string) error {
+	s.Lock()
+	defer s.Unlock()
+	s.File = file
+	s.Accounts = map[string]*AccountState{}
+	return loadJSON(file, &s.Accounts)
+}
+
+// save 写入文件, 调用前需持有锁
+func (s *AccountStates) save() {
+	if s.File == "" {
+		return
+	}
+	if err := saveJSON(s.File, s.Accounts); err != nil {
+		log.Errorf("写入账号状态失败: %v", err)
+	}
+}
+
+// get 返回账号状态, 不存在时创建, 调用前需持有锁
+func (s *AccountStates) get(user int) *AccountState {
+	if s.Accounts == nil {
+		s.Accounts = map[string]*AccountState{}
+	}
+	state, ok := s.Accounts[strconv.Itoa(user)]
+	if !ok {
+		state = &AccountState{}
+		s.Accounts[strconv.Itoa(user)] = state
+	}
+	return state
+}
+
+// Valid 记录账号 Cookie 有效, Cookie 更换后重新计算有效时间
+func (s *AccountStates) Valid(user int, cookieHash string, userID int, nickname string) {
+	s.Lock()
+	defer s.Unlock()
+	state := s.get(user)
+	now := time.Now()
+	if state.CookieHash != cookieHash || state.ValidSince.IsZero() {
+		state.CookieHash = cookieHash
+		state.ValidSince = now
+	}
+	state.UserID = userID
+	state.Nickname = nickname
+	state.CookieExpires = musicUExpires(config.Users[user].Cookies)
+	state.LastValid = now
+	state.AlertedCookie = ""
+	s.save()
+}
+
+// Expired 记录账号 Cookie 失效, 返回最后已知的账号状态, 以及该 Cookie 是否是第一次提醒
+func (s *AccountStates) Expired(user int, cookieHash string) (AccountState, bool) {
+	s.Lock()
+	defer s.Unlock()
+	state := s.get(user)
+	if state.AlertedCookie == cookieHash {
+		return *state, false
+	}
+	state.AlertedCookie = cookieHash
+	s.save()
+	return *state, true
+}
+
+// Get 返回账号状态的副本
+func (s *AccountStates) Get(user int) AccountState {
+	s.Lock()
+	defer s.Unlock()
+	return *s.get(user)
+}
+
+// Refreshed 记录刷新登录状态成功, 更新后的 Cookie 视为重新签发
+func (s *AccountStates) Refreshed(user int, cookieHash string, expires time.Time) {
+	s.Lock()
+	defer s.Unlock()
+	state := s.get(user)
+	now := time.Now()
+	if state.CookieHash != cookieHash {
+		state.CookieHash = cookieHash
+		state.ValidSince = now
+	}
+	state.CookieExpires = expires
+	state.LastRefresh = now
+	state.RefreshError = ""
+	state.WarnedCookie = ""
+	s.save()
+}
+
+// RefreshFailed 记录刷新登录状态失败, 返回该 Cookie 是否是第一次警告
+func (s *AccountStates) RefreshFailed(user int, cookieHash string, err error) bool {
+	s.Lock()
+	defer s.Unlock()
+	state := s.get(user)
+	if err != nil {
+		state.RefreshError = err.Error()
+	}
+	first := state.WarnedCookie != cookieHash
+	state.WarnedCookie = cookieHash
+	s.save()
+	return first
+}
+
+// cookieHash 返回 MUSIC_U 的哈希, 用于判断 Cookie 是否更换
+func cookieHash(cookies []*http.Cookie) string {
+	for _, c := range cookies {
+		if c.Name == "MUSIC_U" {
+			sum := sha256.Sum256([]byte(c.Value))
+			return hex.EncodeToString(sum[:8])
+		}
+	}
+	return ""
+}
+
+// musicUExpires 返回 MUSIC_U 的过期时间, 未记录时返回零值
+func musicUExpires(cookies []*http.Cookie) time.Time {
+	for _, c := range cookies {
+		if c.Name == "MUSIC_U" {
+			return c.Expires
+		}
+	}
+	return time.Time{}
+}
+
+// requestCookies 返回请求时使用的 Cookie, 忽略已过期的 Cookie
+func requestCookies(cookies []*http.Cookie) []*http.Cookie {
+	var result []*http.Cookie
+	for _, c := range cookies {
+		if !c.Expires.IsZero() && c.Expires.Before(time.Now()) {
+			if c.Name == "MUSIC_U" {
+				log.Warnf("User[%d] 的 MUSIC_U 已于 %s 过期", processingUser, c.Expires.Local().Format("2006-01-02 15:04"))
+			}
+			log.Debugf("忽略已过期的 Cookie %s", c.Name)
+			continue
+		}
+		result = append(result, c)
+	}
+	return result
+}
+
+// alertCookieExpired 当前用户 (processingUser) 的 Cookie 失效时立即推送提醒, 同一个 Cookie 只提醒一次
+func alertCookieExpired() {
+	state, first := accountStates.Expired(processingUser, cookieHash(config.Users[processingUser].Cookies))
+	if !first {
+		return
+	}
+	notifiers := loadNotifiers()
+	if len(notifiers) == 0 {
+		return
+	}
+	account := &AccountReport{User: processingUser, UserID: state.UserID, Nickname: state.Nickname}
+	lines := []string{fmt.Sprintf("%s 的 Cookie 已失效", account.Name())}
+	if state.UserID != 0 {
+		lines[0] = fmt.Sprintf("%s (ID: %d) 的 Cookie 已失效", account.Name(), state.UserID)
+	}
+	if !state.ValidSince.IsZero() && state.CookieHash == cookieHash(config.Users[processingUser].Cookies) {
+		lines = append(lines, fmt.Sprintf("该 Cookie 自 %s 起有效, 最后一次有效时间 %s, 共有效 %s",
+			state.ValidSince.Format("2006-01-02 15:04"), state.LastValid.Format("2006-01-02 15:04"), formatDuration(state.LastValid.Sub(state.ValidSince))))
+	} else {
+		lines = append(lines, "未记录到该 Cookie 有效的时间, 请检查配置文件中的 MUSIC_U 是否正确")
+	}
+	lines = append(lines, reloginHint())
+	sendNotification(notifiers, Notification{
+		Title:   "网易云音乐 Cookie 已失效",
+		Content: strings.Join(lines, "\n"),
+		Report:  &RunReport{StartTime: time.Now(), EndTime: time.Now(), Accounts: []*AccountReport{account}},
+		Alert:   true,
+	})
+}
+
+// reloginHint 重新登录的提示, 包含更新当前配置文件的 QuickLogin 命令
+func reloginHint() string {
+	file, err := filepath.Abs(*configFileName)
+	if err != nil {
+		file = *configFileName
+	}
+	return fmt.Sprintf("请使用 QuickLogin 重新登录 (扫码或 -phone / -email 账号登录) 以更新配置文件中的 Cookies: quickLogin -c %s, "+
+		"详见 https://github.com/XiaoMengXinX/Fuck163MusicTasks#关于如何获取-music_u-", file)
+}
+
+// formatDuration 将时长格式化为 "x 天 y 小时" 或 "y 小时 z 分钟"
+func formatDuration(d time.Duration) string {
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	if days > 0 {
+		return fmt.Sprintf("%d 天 %d 小时", days, hours)
+	}
+	return fmt.Sprintf("%d 小时 %d 分钟", hours, int(d.Minutes())%60)
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"crypto/subtle"
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+//go:embed web
+var webFiles embed.FS
+
+// maxLogTail 网页控制台保留的日志行数
+const maxLogTail = 500
+
+// LogTail 保存最近的日志, 用于网页控制台展示
+type LogTail struct {
+	sync.Mutex
+	lines []string
+}
+
+var logTail LogTail
+
+// Levels 实现 log.Hook
+func (t *LogTail) Levels() []log.Level {
+	return log.AllLevels
+}
+
+// Fire 实现 log.Hook
+func (t *LogTail) Fire(entry *log.Entry) error {
+	line, err := (&RedactFormatter{Formatter: new(LogFormatter)}).Format(entry)
+	if err != nil {
+		return err
+	}
+	t.Lock()
+	defer t.Unlock()
+	t.lines = append(t.lines, strings.TrimRight(string(line), "\n"))
+	if len(t.lines) > maxLogTail {
+		t.lines = t.lines[len(t.lines)-maxLogTail:]
+	}
+	return nil
+}
+
+// Lines 返回最近 n 行日志
+func (t *LogTail) Lines(n int) []string {
+	t.Lock()
+	defer t.Unlock()
+	if n <= 0 || n > len(t.lines) {
+		n = len(t.lines)
+	}
+	return append([]string{}, t.lines[len(t.lines)-n:]...)
+}
+
+// dashboardEnabled 是否启用网页控制台
+func dashboardEnabled() bool {
+	return config.Cron.Enabled && config.Server.Enabled && config.Server.Dashboard && config.Server.Token != ""
+}
+
+// registerDashboard 注册网页控制台路由
+func registerDashboard(mux *http.ServeMux) {
+	if !config.Server.Dashboard {
+		return
+	}
+	if config.Server.Token == "" {
+		log.Warnln("[Server] 未设置 Server.Token, 网页控制台未启用")
+		return
+	}
+	log.AddHook(&logTail)
+	static, err := fs.Sub(webFiles, "web")
+	if err != nil {
+		log.Errorln(err)
+		return
+	}
+	mux.Handle("/dashboard/", http.StripPrefix("/dashboard/", http.FileServer(http.FS(static))))
+	mux.Handle("/dashboard/api/status", requireToken(http.HandlerFunc(handleStatus)))
+	mux.Handle("/dashboard/api/logs", requireToken(http.HandlerFunc(handleLogs)))
+	mux.Handle("/dashboard/api/run", requireToken(http.HandlerFunc(handleRun)))
+}
+
+// requireToken 校验 Authorization: Bearer <Token> 或 ?token=<Token>
+func requireToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" {
+			token = r.URL.Query().Get("token")
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(config.Server.Token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleLogs 返回最近的日志
+func handleLogs(w http.ResponseWriter, r *http.Request) {
+	n, _ := strconv.Atoi(r.URL.Query().Get("n"))
+	if n == 0 {
+		n = 200
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(logTail.Lines(n))
+}
+
+// handleRun 手动运行某个用户的全部任务或单个任务
+func handleRun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user, err := strconv.Atoi(r.FormValue("user"))
+	if err != nil || user < 0 || user >= len(config.Users) {
+		http.Error(w, "invalid user", http.StatusBadRequest)
+		return
+	}
+	task := r.FormValue("task")
+	if !isValidTask(task) {
+		http.Error(w, "invalid task", http.StatusBadRequest)
+		return
+	}
+	go func() {
+		log.Printf("[Server] 手动运行 User[%d] 任务: %s", user, taskName(task))
+		err := runUserTasks(user, task)
+		if err != nil {
+			log.Errorf("[Server] 手动运行 User[%d] 任务失败: %v", user, err)
+		}
+	}()
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func taskName(task string) string {
+	if task == "" {
+		return "all"
+	}
+	return task
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/XiaoMengXinX/Music163Api-Go/api"
+	"github.com/XiaoMengXinX/Music163Api-Go/types"
+	"github.com/XiaoMengXinX/Music163Api-Go/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// PendingEvent 待删除的动态
+	PendingEvent = "event"
+	// PendingComment 待删除的评论
+	PendingComment = "comment"
+	// PendingMlog 待删除的 Mlog
+	PendingMlog = "mlog"
+)
+
+const (
+	// pendingMaxAttempts 重试删除的最大次数, 超过后放弃删除
+	pendingMaxAttempts = 10
+	// pendingMaxAge 待删除记录的最长保留时间, 超过后放弃删除
+	pendingMaxAge = 30 * 24 * time.Hour
+)
+
+// PendingDeletion 待删除内容记录
+type PendingDeletion struct {
+	Type      string    `json:"Type"`
+	UserID    int       `json:"UserID"`
+	ID        int       `json:"ID"`
+	ResType   string    `json:"ResType,omitempty"`
+	ResID     int       `json:"ResID,omitempty"`
+	CreatedAt time.Time `json:"CreatedAt"`
+	Attempts  int       `json:"Attempts,omitempty"`
+}
+
+// deleteError 删除接口返回的错误
+type deleteError struct {
+	Code    int
+	Message string
+}
+
+func (e *deleteError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("代码: %d", e.Code)
+	}
+	return fmt.Sprintf("代码: %d, 原因: \"%s\"", e.Code, e.Message)
+}
+
+// PendingJournal 待删除内容日志, 发送成功后写入, 删除成功后移除
+type PendingJournal struct {
+	sync.Mutex
+	File    string
+	Entries []PendingDeletion
+}
+
+var pendingJournal PendingJournal
+
+// dataPath 返回数据目录下的文件路径
+func dataPath(name string) string {
+	dir := config.DataDir
+	if dir == "" {
+		dir = "data"
+	}
+	return filepath.Join(configRelPath(dir), name)
+}
+
+// configRelPath 将相对路径转换为相对于配置文件所在目录的路径, 使运行时的工作目录不影响文件位置
+func configRelPath(p string) string {
+	if p == "" || filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(filepath.Dir(*configFileName), p)
+}
+
+// Load 从文件读取待删除内容
+func (j *PendingJournal) Load(file string) error {
+	j.Lock()
+	defer j.Unlock()
+	j.File = file
+	j.Entries = nil
+	return loadJSON(file, &j.Entries)
+}
+
+// save 写入文件, 调用前需持有锁
+func (j *PendingJournal) save() error {
+	if j.File == "" {
+		return nil
+	}
+	return saveJSON(j.File, j.Entries)
+}
+
+// loadJSON 从 JSON 文件读取数据, 文件不存在或为空时不做任何修改
+func loadJSON(file string, v interface{}) error {
+	fileData, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(fileData) == 0 {
+		return nil
+	}
+	return json.Unmarshal(fileData, v)
+}
+
+// saveJSON 先写入临时文件再重命名, 避免写入中断导致文件损坏
+func saveJSON(file string, v interface{}) error {
+	err := os.MkdirAll(filepath.Dir(file), os.ModePerm)
+	if err != nil {
+		return err
+	}
+	fileData, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmpFile := file + ".tmp"
+	err = ioutil.WriteFile(tmpFile, fileData, 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, file)
+}
+
+// Add 记录一条待删除内容
+func (j *PendingJournal) Add(entry PendingDeletion) {
+	j.Lock()
+	defer j.Unlock()
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	j.Entries = append(j.Entries, entry)
+	if err := j.save(); err != nil {
+		log.Errorf("写入待删除记录失败: %v", err)
+	}
+}
+
+// Remove 移除一条待删除内容
+func (j *PendingJournal) Remove(entryType string, id int) {
+	j.Lock()
+	defer j.Unlock()
+	for i, e := range j.Entries {
+		if e.Type == entryType && e.ID == id {
+			j.Entries = append(j.Entries[:i], j.Entries[i+1:]...)
+			if err := j.save(); err != nil {
+				log.Errorf("写入待删除记录失败: %v", err)
+			}
+			return
+		}
+	}
+}
+
+// Failed 记录一次删除失败, 返回是否已超过最大重试次数或最长保留时间, 超过时移除该记录
+func (j *PendingJournal) Failed(entryType string, id int) bool {
+	j.Lock()
+	defer j.Unlock()
+	for i, e := range j.Entries {
+		if e.Type != entryType || e.ID != id {
+			continue
+		}
+		e.Attempts++
+		expired := e.Attempts >= pendingMaxAttempts || time.Since(e.CreatedAt) > pendingMaxAge
+		if expired {
+			j.Entries = append(j.Entries[:i], j.Entries[i+1:]...)
+		} else {
+			j.Entries[i] = e
+		}
+		if err := j.save(); err != nil {
+			log.Errorf("写入待删除记录失败: %v", err)
+		}
+		return expired
+	}
+	return false
+}
+
+// ForUser 返回某用户的所有待删除内容
+func (j *PendingJournal) ForUser(userID int) []PendingDeletion {
+	j.Lock()
+	defer j.Unlock()
+	var entries []PendingDeletion
+	for _, e := range j.Entries {
+		if e.UserID == userID {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// sweepPendingDeletions 重试删除该用户之前未能删除的内容
+func sweepPendingDeletions(userData types.LoginStatusData, data utils.RequestData) {
+	entries := pendingJournal.ForUser(userData.Account.Id)
+	if len(entries) == 0 {
+		return
+	}
+	log.Printf("[%s] 发现 %d 条未删除的内容, 正在重试删除", userData.Profile.Nickname, len(entries))
+	for _, e := range entries {
+		err := deletePending(userData, data, e)
+		if err != nil {
+			log.Errorf("[%s] 重试删除失败, 类型: %s, ID: %d, 原因: %v", userData.Profile.Nickname, e.Type, e.ID, err)
+			if pendingJournal.Failed(e.Type, e.ID) {
+				log.Warnf("[%s] 多次重试仍未能删除, 放弃删除, 类型: %s, ID: %d, 请手动删除", userData.Profile.Nickname, e.Type, e.ID)
+			}
+			continue
+		}
+		log.Printf("[%s] 重试删除成功, 类型: %s, ID: %d", userData.Profile.Nickname, e.Type, e.ID)
+		pendingJournal.Remove(e.Type, e.ID)
+	}
+}
+
+func deletePending(userData types.LoginStatusData, data utils.RequestData, e PendingDeletion) error {
+	switch e.Type {
+	case PendingEvent, PendingMlog:
+		action := AuditEventDelete
+		if e.Type == PendingMlog {
+			action = AuditMlogDelete
+		}
+		result, err := delEvent(userData, data, e.ID, action)
+		if err != nil {
+			return err
+		}
+		if result.Code != 200 {
+			return &deleteError{Code: result.Code, Message: result.Message}
+		}
+	case PendingComment:
+		result, err := delComment(userData, data, api.CommentConfig{
+			ResType:   e.ResType,
+			ResID:     e.ResID,
+			CommentID: e.ID,
+		})
+		if err != nil {
+			return err
+		}
+		if result.Code != 200 {
+			return &deleteError{Code: result.Code, Message: responseMessage(result.RawJson)}
+		}
+	default:
+		return fmt.Errorf("未知类型 \"%s\"", e.Type)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPendingJournal(t *testing.T) {
+	old := time.Now().Add(-pendingMaxAge - time.Hour)
+	tests := []struct {
+		name        string
+		entries     []PendingDeletion
+		failures    int
+		wantExpired bool
+		wantLeft    int
+		wantTries   int
+	}{
+		{"失败一次后保留", []PendingDeletion{{Type: PendingEvent, ID: 1}}, 1, false, 1, 1},
+		{"未达到最大次数", []PendingDeletion{{Type: PendingEvent, ID: 1}}, pendingMaxAttempts - 1, false, 1, pendingMaxAttempts - 1},
+		{"达到最大次数后移除", []PendingDeletion{{Type: PendingEvent, ID: 1}}, pendingMaxAttempts, true, 0, 0},
+		{"超过最长保留时间后移除", []PendingDeletion{{Type: PendingEvent, ID: 1, CreatedAt: old}}, 1, true, 0, 0},
+		{"只修改匹配的记录", []PendingDeletion{{Type: PendingComment, ID: 1}, {Type: PendingEvent, ID: 1}}, pendingMaxAttempts, true, 1, 0},
+		{"记录不存在", []PendingDeletion{{Type: PendingMlog, ID: 2}}, 1, false, 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := &PendingJournal{}
+			for _, e := range tt.entries {
+				j.Add(e)
+			}
+			var expired bool
+			for i := 0; i < tt.failures; i++ {
+				expired = j.Failed(PendingEvent, 1)
+			}
+			if expired != tt.wantExpired {
+				t.Errorf("Failed() = %v, want %v", expired, tt.wantExpired)
+			}
+			if len(j.Entries) != tt.wantLeft {
+				t.Fatalf("剩余 %d 条记录, want %d", len(j.Entries), tt.wantLeft)
+			}
+			for _, e := range j.Entries {
+				if e.CreatedAt.IsZero() {
+					t.Errorf("Add 应记录创建时间")
+				}
+				if e.Type == PendingEvent && e.ID == 1 && e.Attempts != tt.wantTries {
+					t.Errorf("Attempts = %d, want %d", e.Attempts, tt.wantTries)
+				}
+			}
+		})
+	}
+}
+
+func TestPendingJournalRemove(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "pending_delete.json")
+	j := &PendingJournal{File: file}
+	j.Add(PendingDeletion{Type: PendingEvent, UserID: 1, ID: 1})
+	j.Add(PendingDeletion{Type: PendingComment, UserID: 1, ID: 1})
+	j.Add(PendingDeletion{Type: PendingEvent, UserID: 2, ID: 2})
+	j.Remove(PendingEvent, 1)
+	j.Remove(PendingMlog, 3) // 不存在的记录
+
+	loaded := &PendingJournal{}
+	if err := loaded.Load(file); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		userID int
+		want   []string
+	}{
+		{1, []string{PendingComment}},
+		{2, []string{PendingEvent}},
+		{3, nil},
+	}
+	for _, tt := range tests {
+		entries := loaded.ForUser(tt.userID)
+		if len(entries) != len(tt.want) {
+			t.Fatalf("ForUser(%d) = %d 条, want %d", tt.userID, len(entries), len(tt.want))
+		}
+		for i, e := range entries {
+			if e.Type != tt.want[i] {
+				t.Errorf("ForUser(%d)[%d].Type = %s, want %s", tt.userID, i, e.Type, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestDeleteError(t *testing.T) {
+	tests := []struct {
+		err  *deleteError
+		want string
+	}{
+		{&deleteError{Code: 400}, "代码: 400"},
+		{&deleteError{Code: 400, Message: "评论不存在"}, "代码: 400, 原因: \"评论不存在\""},
+	}
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.want {
+			t.Errorf("Error() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestResponseMessage(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{`{"code":400,"message":"参数错误"}`, "参数错误"},
+		{`{"code":400,"msg":"评论不存在"}`, "评论不存在"},
+		{`{"code":200}`, ""},
+		{`not json`, ""},
+	}
+	for _, tt := range tests {
+		if got := responseMessage(tt.raw); got != tt.want {
+			t.Errorf("responseMessage(%s) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
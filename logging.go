@@ -0,0 +1,257 @@
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// LogFormatter 自定义 log 格式
+type LogFormatter struct{}
+
+// Format 自定义 log 格式
+func (s *LogFormatter) Format(entry *log.Entry) ([]byte, error) {
+	timestamp := time.Now().Local().Format("2006/01/02 15:04:05")
+	var msg string
+	msg = fmt.Sprintf("%s [%s] %s (%s:%d)\n", timestamp, strings.ToUpper(entry.Level.String()), entry.Message, path.Base(entry.Caller.File), entry.Caller.Line)
+	return []byte(msg), nil
+}
+
+// newJSONFormatter 每行一条 JSON 的 log 格式
+func newJSONFormatter() *log.JSONFormatter {
+	return &log.JSONFormatter{
+		TimestampFormat: time.RFC3339,
+		CallerPrettyfier: func(frame *runtime.Frame) (function string, file string) {
+			return "", fmt.Sprintf("%s:%d", path.Base(frame.File), frame.Line)
+		},
+	}
+}
+
+// setupLogging 根据配置设置日志输出
+func setupLogging(logConfig LogConfig) {
+	if strings.EqualFold(logConfig.Format, "json") {
+		log.SetFormatter(&RedactFormatter{Formatter: newJSONFormatter()})
+	} else {
+		log.SetFormatter(&RedactFormatter{Formatter: new(LogFormatter)})
+	}
+	if logConfig.StdoutOnly {
+		log.SetOutput(os.Stdout)
+		return
+	}
+	dir := logConfig.Dir
+	if dir == "" {
+		dir = "log"
+	}
+	writer, err := NewRotatingWriter(configRelPath(dir), logConfig)
+	if err != nil {
+		log.Errorf("创建日志文件失败, 日志将只输出到终端: %v", err)
+		log.SetOutput(os.Stdout)
+		return
+	}
+	log.SetOutput(io.MultiWriter(writer, os.Stdout))
+}
+
+// RotatingWriter 按日期 (以及大小) 切割的日志文件
+type RotatingWriter struct {
+	sync.Mutex
+	dir     string
+	config  LogConfig
+	file    *os.File
+	date    string
+	size    int64
+	cleaned chan struct{}
+}
+
+// NewRotatingWriter 创建日志文件
+func NewRotatingWriter(dir string, logConfig LogConfig) (*RotatingWriter, error) {
+	err := os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+	w := &RotatingWriter{dir: dir, config: logConfig, cleaned: make(chan struct{}, 1)}
+	w.Lock()
+	defer w.Unlock()
+	err = w.open(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// currentName 当前日志文件名
+func (w *RotatingWriter) currentName() string {
+	return filepath.Join(w.dir, w.date+".log")
+}
+
+// open 打开当天的日志文件, 调用前需持有锁
+func (w *RotatingWriter) open(now time.Time) error {
+	if w.file != nil {
+		_ = w.file.Close()
+	}
+	w.date = now.Local().Format("2006-01-02")
+	file, err := os.OpenFile(w.currentName(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	w.file = file
+	w.size = info.Size()
+	w.cleanup()
+	return nil
+}
+
+// Write 实现 io.Writer
+func (w *RotatingWriter) Write(p []byte) (int, error) {
+	w.Lock()
+	defer w.Unlock()
+	now := time.Now()
+	if now.Local().Format("2006-01-02") != w.date {
+		if err := w.open(now); err != nil {
+			return 0, err
+		}
+	} else if w.config.Rotate == "size" && w.config.MaxSize > 0 && w.size+int64(len(p)) > int64(w.config.MaxSize)*1024*1024 {
+		_ = w.file.Close()
+		w.file = nil
+		rotated := w.rotatedName(now)
+		if err := os.Rename(w.currentName(), rotated); err != nil {
+			return 0, err
+		}
+		if err := w.open(now); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// rotatedName 按大小切割时旧日志的文件名, 同一秒内多次切割时添加序号, 如 2021-08-24-150405.1.log
+func (w *RotatingWriter) rotatedName(now time.Time) string {
+	base := filepath.Join(w.dir, fmt.Sprintf("%s-%s", w.date, now.Local().Format("150405")))
+	name := base + ".log"
+	for i := 1; fileExists(name) || fileExists(name+".gz"); i++ {
+		name = fmt.Sprintf("%s.%d.log", base, i)
+	}
+	return name
+}
+
+func fileExists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
+
+// cleanup 在后台压缩及清理旧日志, 调用前需持有锁
+func (w *RotatingWriter) cleanup() {
+	if !w.config.Compress && w.config.MaxAge <= 0 && w.config.MaxBackups <= 0 {
+		return
+	}
+	select {
+	case w.cleaned <- struct{}{}:
+	default:
+		return // 已有清理任务在运行
+	}
+	current := w.currentName()
+	go func() {
+		defer func() { <-w.cleaned }()
+		err := cleanupLogs(w.dir, current, w.config)
+		if err != nil {
+			log.Errorf("清理旧日志失败: %v", err)
+		}
+	}()
+}
+
+// cleanupLogs 压缩旧日志, 并按天数和数量删除过期日志
+func cleanupLogs(dir, current string, logConfig LogConfig) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	type logFile struct {
+		name    string
+		modTime time.Time
+	}
+	var files []logFile
+	for _, e := range entries {
+		name := filepath.Join(dir, e.Name())
+		if e.IsDir() || name == current {
+			continue
+		}
+		if !strings.HasSuffix(name, ".log") && !strings.HasSuffix(name, ".log.gz") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		if logConfig.MaxAge > 0 && time.Since(info.ModTime()) > time.Duration(logConfig.MaxAge)*24*time.Hour {
+			if err := os.Remove(name); err != nil {
+				log.Errorln(err)
+			}
+			continue
+		}
+		if logConfig.Compress && strings.HasSuffix(name, ".log") {
+			if err := gzipFile(name); err != nil {
+				log.Errorln(err)
+			} else {
+				name += ".gz"
+			}
+		}
+		files = append(files, logFile{name: name, modTime: info.ModTime()})
+	}
+	if logConfig.MaxBackups > 0 && len(files) > logConfig.MaxBackups {
+		sort.Slice(files, func(i, j int) bool {
+			return files[i].modTime.After(files[j].modTime)
+		})
+		for _, f := range files[logConfig.MaxBackups:] {
+			if err := os.Remove(f.name); err != nil {
+				log.Errorln(err)
+			}
+		}
+	}
+	return nil
+}
+
+// gzipFile 压缩文件并删除原文件
+func gzipFile(name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	dst, err := os.OpenFile(name+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(dst)
+	_, err = io.Copy(gz, src)
+	if err == nil {
+		err = gz.Close()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(name + ".gz")
+		return err
+	}
+	_ = os.Chtimes(name+".gz", info.ModTime(), info.ModTime())
+	_ = src.Close()
+	return os.Remove(name)
+}
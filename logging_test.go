@@ -0,0 +1,32 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotatedName(t *testing.T) {
+	dir := t.TempDir()
+	w := &RotatingWriter{dir: dir, date: "2021-08-24"}
+	now := time.Date(2021, 8, 24, 15, 4, 5, 0, time.Local)
+	tests := []struct {
+		existing string
+		want     string
+	}{
+		{"", "2021-08-24-150405.log"},
+		{"2021-08-24-150405.log", "2021-08-24-150405.1.log"},
+		{"2021-08-24-150405.1.log.gz", "2021-08-24-150405.2.log"},
+	}
+	for _, tt := range tests {
+		if tt.existing != "" {
+			if err := ioutil.WriteFile(filepath.Join(dir, tt.existing), nil, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got := w.rotatedName(now); got != filepath.Join(dir, tt.want) {
+			t.Errorf("rotatedName() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -1,19 +1,16 @@
 package main
 
 import (
-	"bytes"
 	"encoding/json"
 	"flag"
 	"fmt"
-	"io"
 	"io/ioutil"
 	"math/rand"
-	"net/http"
-	"net/url"
 	"os"
-	"path"
 	"runtime"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/XiaoMengXinX/Music163Api-Go/api"
@@ -23,23 +20,13 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-// LogFormatter 自定义 log 格式
-type LogFormatter struct{}
-
-// Format 自定义 log 格式
-func (s *LogFormatter) Format(entry *log.Entry) ([]byte, error) {
-	timestamp := time.Now().Local().Format("2006/01/02 15:04:05")
-	var msg string
-	msg = fmt.Sprintf("%s [%s] %s (%s:%d)\n", timestamp, strings.ToUpper(entry.Level.String()), entry.Message, path.Base(entry.Caller.File), entry.Caller.Line)
-	return []byte(msg), nil
-}
-
 var config Config
 var commentLag RandomNum
 var eventLag RandomNum
 var msgLag RandomNum
 var mlogLag RandomNum
 var processingUser int
+var runMutex sync.Mutex
 var circleID string
 var pushMsg string
 var configFileName = flag.String("c", "config.json", "Config filename") // 从 cli 参数读取配置文件名
@@ -55,29 +42,9 @@ var (
 )
 
 func init() {
-	checkPathExists("./log")
-	timeStamp := time.Now().Local().Format("2006-01-02")
-	logFile := fmt.Sprintf("./log/%v.log", timeStamp)
-	file, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	if err != nil {
-		log.Error(err)
-	}
-	output := io.MultiWriter(file, os.Stdout)
-	log.SetOutput(output)
-	log.SetFormatter(&log.TextFormatter{
-		DisableColors:          false,
-		FullTimestamp:          true,
-		DisableLevelTruncation: true,
-		PadLevelText:           true,
-	})
-	log.SetFormatter(new(LogFormatter))
+	log.SetOutput(os.Stdout) // 读取配置文件前只输出到终端
+	log.SetFormatter(&RedactFormatter{Formatter: new(LogFormatter)})
 	log.SetReportCaller(true)
-	flag.Parse() // 解析命令行参数
-	if *isDEBUG {
-		log.SetLevel(log.DebugLevel)
-	} else {
-		log.SetLevel(log.InfoLevel)
-	}
 }
 
 func main() {
@@ -88,6 +55,13 @@ func main() {
 		}
 	}()
 
+	flag.Parse() // 解析命令行参数
+	if *isDEBUG {
+		log.SetLevel(log.DebugLevel)
+	} else {
+		log.SetLevel(log.InfoLevel)
+	}
+
 	if *printVersion {
 		fmt.Printf(`Fuck163MusicTasks %s (%s)
 Build Hash: %s
@@ -121,8 +95,37 @@ Build ARCH: %s
 		}
 	}()
 
-	if config.DEBUG { // 检查是否开启 DEBUG 模式
+	registerConfigSecrets()  // 隐藏日志及推送内容中的 Cookie 和 Token
+	setupLogging(config.Log) // 设置日志输出
+	utils.SetLogger(log.StandardLogger())
+
+	if config.DEBUG || *isDEBUG { // 检查是否开启 DEBUG 模式
 		log.SetLevel(log.DebugLevel)
+		utils.DEBUG = true
+	}
+
+	err := accountStates.Load(dataPath("accounts.json")) // 读取账号状态
+	if err != nil {
+		log.Errorf("读取账号状态失败: %v", err)
+	}
+	err = deviceIDs.Load(dataPath("devices.json")) // 读取自动生成的设备 ID
+	if err != nil {
+		log.Errorf("读取设备 ID 失败: %v", err)
+	}
+	setupAccountNetwork() // 设置各账号的代理及设备信息
+
+	switch flag.Arg(0) { // 子命令
+	case "audit":
+		os.Exit(runAuditCommand(flag.Args()[1:]))
+	case "check", "status":
+		os.Exit(runCheckCommand(flag.Args()[1:]))
+	case "":
+	default:
+		log.Fatalf("未知命令 \"%s\"", flag.Arg(0))
+	}
+
+	if err = parseContentTemplates(); err != nil { // 解析 Content 模板
+		log.Fatalf("解析 Content 失败: %v", err)
 	}
 
 	commentLag.Set(config.CommentConfig.LagConfig) // 设置延迟
@@ -130,9 +133,25 @@ Build ARCH: %s
 	msgLag.Set(config.SendMsgConfig.LagConfig)
 	mlogLag.Set(config.SendMlogConfig.LagConfig)
 
-	startTasks()
+	err = pendingJournal.Load(dataPath("pending_delete.json")) // 读取待删除内容记录
+	if err != nil {
+		log.Errorf("读取待删除记录失败: %v", err)
+	}
+	err = notifyState.Load(dataPath("notify_state.json")) // 读取推送去重及汇总状态
+	if err != nil {
+		log.Errorf("读取推送状态失败: %v", err)
+	}
+	err = outbox.Load(dataPath("outbox.json")) // 读取推送发件箱
+	if err != nil {
+		log.Errorf("读取推送发件箱失败: %v", err)
+	}
+
+	startServer()
+	runScheduledTasks()
+	if config.Cron.Enabled {
+		startOutboxRetry()
+	}
 	startCron()
-	startPushMsg()
 }
 
 func startCron() {
@@ -149,6 +168,8 @@ func startCron() {
 		entryID, err = c.AddFunc(fmt.Sprintf("%s", config.Cron.Expression), func() {
 			entry := c.Entry(entryID)
 			log.Printf("[Cron] 任务已运行, 下次运行时间 %s", entry.Next)
+			metrics.Set("f163_next_run_timestamp_seconds", float64(entry.Next.Unix()))
+			daemonStatus.SetNextRun(entry.Next)
 			if config.Cron.EnableLag {
 				lag := RandomNum{}
 				config.Cron.LagConfig.RandomLag = true
@@ -159,7 +180,7 @@ func startCron() {
 					time.Sleep(time.Duration(randomLag) * time.Second)
 				}
 			}
-			startTasks()
+			runScheduledTasks()
 		})
 		if err != nil {
 			log.Fatal(err)
@@ -168,74 +189,172 @@ func startCron() {
 		c.Start()
 		entry := c.Entry(entryID)
 		log.Printf("[Cron] 任务已启动, 下次运行时间 %s", entry.Next)
+		metrics.Set("f163_next_run_timestamp_seconds", float64(entry.Next.Unix()))
+		daemonStatus.SetNextRun(entry.Next)
 		select {}
 	}
 }
 
+// runScheduledTasks 运行所有用户的任务并推送结果, 整个过程持有 runMutex, 避免期间手动运行的任务替换本次的运行报告
+func runScheduledTasks() {
+	runMutex.Lock()
+	defer runMutex.Unlock()
+	startTasks()
+	startPushMsg()
+}
+
+// startTasks 运行所有用户的任务, 调用前需持有 runMutex
 func startTasks() {
+	daemonStatus.SetRunning(true)
+	defer daemonStatus.SetRunning(false)
+	newRunReport()
+	defer func() { runReport.EndTime = time.Now() }()
+	var failed bool
 	for processingUser = 0; processingUser < len(config.Users); processingUser++ { // 开始执行自动任务
-		data := utils.RequestData{
-			Cookies: config.Users[processingUser].Cookies,
+		userData, data, ok := loginUser()
+		if !ok {
+			failed = true
+			continue
 		}
-		userData, err := api.GetLoginStatus(data)
+		err := autoTasks(userData, data)
 		if err != nil {
 			log.Errorln(err)
+			reportAccount().Errors = append(reportAccount().Errors, redactor.Redact(err.Error()))
+			failed = true
 		}
-		if userData.Profile.UserId == 0 {
-			log.Errorf("获取 User[%d] 登录状态失败, 请检查 MUSIC_U 是否失效", processingUser)
-		} else {
-			err = autoTasks(userData, data)
-			if err != nil {
-				log.Errorln(err)
-			}
-		}
+		setRunResult(processingUser, err)
+	}
+	metrics.Inc("f163_runs_total")
+	if failed {
+		metrics.Inc("f163_runs_failed_total")
 	}
 }
 
-// 推送消息
-func startPushMsg() {
-	// PushPlus
-	if config.PushPlusToken != "" {
-		// 消息内容
-		content := fmt.Sprintf("网易云音乐自动任务已完成")
-		content += pushMsg
-		// 推送相关
-		data := url.Values{}
-		data.Set("token", config.PushPlusToken)
-		data.Set("title", "网易云音乐自动任务")
-		data.Set("content", content)
-		res, err := http.PostForm("http://www.pushplus.plus/send", data)
+// musicianTaskKeywords 可单独运行的音乐人任务及其对应的任务关键词
+var musicianTaskKeywords = map[string]string{
+	"share":         "分享",
+	"musician_sign": "签到",
+	"event":         "动态",
+	"comment":       "评论",
+	"message":       "私信",
+	"mlog":          "mlog",
+	"musician_said": "主创说",
+	"circle":        "云圈",
+}
+
+// isValidTask 检查是否为可单独运行的任务
+func isValidTask(task string) bool {
+	_, ok := musicianTaskKeywords[task]
+	return ok || task == "" || task == "sign" || task == "vip_reward"
+}
+
+// runUserTasks 运行某个用户的全部任务, task 不为空时只运行指定任务
+func runUserTasks(user int, task string) (err error) {
+	runMutex.Lock()
+	defer runMutex.Unlock()
+	if user < 0 || user >= len(config.Users) {
+		return fmt.Errorf("User[%d] 不存在", user)
+	}
+	if !isValidTask(task) {
+		return fmt.Errorf("未知任务 \"%s\"", task)
+	}
+	daemonStatus.SetRunning(true)
+	defer daemonStatus.SetRunning(false)
+	newRunReport()
+	defer func() { runReport.EndTime = time.Now() }()
+	metrics.Inc("f163_runs_total")
+	defer func() {
 		if err != nil {
-			log.Errorln(err)
+			metrics.Inc("f163_runs_failed_total")
 		}
-		defer res.Body.Close()
-		log.Println("PushPlus推送：", res.Status)
-	}
-	// Server酱
-	if config.ServerSendKey != "" {
-		sendUrl := fmt.Sprintf("https://sc.ftqq.com/%s.send", config.ServerSendKey)
-		// 消息内容
-		type Message struct {
-			Title string `json:"title"`
-			Desp  string `json:"desp"`
-		}
-		title := fmt.Sprintf("网易云音乐自动任务")
-		content := fmt.Sprintf("网易云音乐自动任务已完成")
-		content += pushMsg
-		content = strings.ReplaceAll(content, "\n", "\n\n")
-		message := Message{
-			Title: title,
-			Desp:  content,
-		}
-		messageJson, err := json.Marshal(message)
-		// 推送相关
-		res, err := http.Post(sendUrl, "application/json", bytes.NewReader(messageJson))
-		if err != nil {
-			log.Errorln(err)
+	}()
+	processingUser = user
+	userData, data, ok := loginUser()
+	if !ok {
+		return fmt.Errorf("获取 User[%d] 登录状态失败", user)
+	}
+	switch task {
+	case "":
+		err = autoTasks(userData, data)
+		setRunResult(user, err)
+	case "sign":
+		err = userSignTask(userData, data)
+		recordTask(task, err)
+	case "vip_reward":
+		err = vipGrowthpointTask(userData, data)
+		recordTask(task, err)
+	case "circle":
+		if err = resolveCircleID(userData, data); err != nil {
+			recordTask(task, err)
+			return err
 		}
-		defer res.Body.Close()
-		log.Println("Server酱推送：", res.Status)
+		err = musicianTasks(userData, data, []string{musicianTaskKeywords[task]}, 0)
+	default:
+		err = musicianTasks(userData, data, []string{musicianTaskKeywords[task]}, 0)
+	}
+	return err
+}
+
+// loginUser 获取当前用户 (processingUser) 的登录状态
+func loginUser() (types.LoginStatusData, utils.RequestData, bool) {
+	data := accountRequestData(processingUser)
+	userData, err := api.GetLoginStatus(data)
+	if err != nil {
+		log.Errorln(err)
+	}
+	report := reportAccount()
+	if userData.Profile.UserId == 0 {
+		log.Errorf("获取 User[%d] 登录状态失败, 请检查 MUSIC_U 是否失效", processingUser)
+		report.CookieValid = false
+		metrics.Set("f163_cookie_valid", 0, "user", strconv.Itoa(processingUser))
+		daemonStatus.Update(processingUser, func(s *AccountStatus) {
+			s.CookieValid = false
+			s.LastRun = time.Now()
+			s.LastResult = ResultCookieInvalid
+			s.LastError = ""
+		})
+		if err == nil { // 请求失败时无法确定 Cookie 是否失效, 不发送提醒
+			alertCookieExpired()
+		}
+		return userData, data, false
+	}
+	metrics.Set("f163_cookie_valid", 1, "user", strconv.Itoa(processingUser))
+	report.CookieValid = true
+	report.UserID = userData.Account.Id
+	report.Nickname = userData.Profile.Nickname
+	accountStates.Valid(processingUser, cookieHash(data.Cookies), userData.Account.Id, userData.Profile.Nickname)
+	data = refreshSession(data)
+	daemonStatus.Update(processingUser, func(s *AccountStatus) {
+		s.UserID = userData.Account.Id
+		s.Nickname = userData.Profile.Nickname
+		s.AvatarURL = userData.Profile.AvatarUrl
+		s.CookieValid = true
+	})
+	if dashboardEnabled() {
+		vipInfo, err := api.GetVipInfo(data)
+		if err == nil {
+			daemonStatus.Update(processingUser, func(s *AccountStatus) {
+				s.VipLevel = vipInfo.Data.RedVipLevel
+			})
+		}
+	}
+	return userData, data, true
+}
+
+// 推送消息
+func startPushMsg() {
+	notifiers := loadNotifiers()
+	if len(notifiers) == 0 {
+		return
+	}
+	if !config.Cron.Enabled && outbox.Len() != 0 { // 单次运行模式下重试上次未发送成功的推送
+		retryOutbox(notifiers, true)
 	}
+	sendNotification(notifiers, Notification{
+		Title:   "网易云音乐自动任务",
+		Content: "网易云音乐自动任务已完成" + pushMsg,
+		Report:  runReport,
+	})
 }
 
 func autoTasks(userData types.LoginStatusData, data utils.RequestData) error {
@@ -245,7 +364,9 @@ func autoTasks(userData types.LoginStatusData, data utils.RequestData) error {
 			log.Errorln(err)
 		}
 	}()
+	sweepPendingDeletions(userData, data)
 	err := userSignTask(userData, data)
+	recordTask("sign", err)
 	if err != nil {
 		log.Errorln(err)
 	}
@@ -253,8 +374,12 @@ func autoTasks(userData types.LoginStatusData, data utils.RequestData) error {
 	if err != nil {
 		return err
 	}
+	circleID = ""
 	if strings.Contains(userDetail.CurrentExpert.RoleName, "网易音乐人") {
 		artistDetail, err := api.GetArtistHomepage(data, int64(userDetail.Profile.ArtistId))
+		if err != nil {
+			log.Errorln(err)
+		}
 		parseCircleID(artistDetail)
 		autoTasks, err := checkCloudBean(userData, data)
 		if err != nil {
@@ -275,6 +400,7 @@ func autoTasks(userData types.LoginStatusData, data utils.RequestData) error {
 	}
 	if config.AutoGetVipGrowthpoint {
 		err := vipGrowthpointTask(userData, data)
+		recordTask("vip_reward", err)
 		if err != nil {
 			return err
 		}
@@ -282,24 +408,27 @@ func autoTasks(userData types.LoginStatusData, data utils.RequestData) error {
 	return nil
 }
 
-func musicianTasks(userData types.LoginStatusData, data utils.RequestData, autoTasks []string, i int) {
+// musicianTasks 运行 autoTasks[i] 对应的音乐人任务, 返回该任务的错误
+func musicianTasks(userData types.LoginStatusData, data utils.RequestData, autoTasks []string, i int) (err error) {
 	defer func() {
-		err := recover()
-		if err != nil {
-			log.Errorln(err)
+		if r := recover(); r != nil {
+			log.Errorln(r)
+			err = fmt.Errorf("%v", r)
 		}
 	}()
 	switch {
 	case strings.Contains(autoTasks[i], "分享"):
 		log.Printf("[%s] 执行分享音乐任务中", userData.Profile.Nickname)
-		err := shareMusicTask(userData, data)
+		err = shareMusicTask(userData, data)
+		recordTask("share", err)
 		if err != nil {
 			log.Println(err)
 		}
 		log.Printf("[%s] 分享音乐任务执行完成", userData.Profile.Nickname)
 	case strings.Contains(autoTasks[i], "签到"):
 		log.Printf("[%s] 执行音乐人签到任务中", userData.Profile.Nickname)
-		result, err := api.MusicianSign(data)
+		var result types.MusicianSignData
+		result, err = musicianSign(userData, data)
 		if err != nil {
 			log.Println(err)
 		}
@@ -307,10 +436,15 @@ func musicianTasks(userData types.LoginStatusData, data utils.RequestData, autoT
 			log.Printf("[%s] 音乐人签到成功", userData.Profile.Nickname)
 		} else {
 			log.Printf("[%s] 音乐人签到失败: %s", userData.Profile.Nickname, result.Message)
+			if err == nil {
+				err = fmt.Errorf("%s", result.Message)
+			}
 		}
+		recordTask("musician_sign", err)
 	case strings.Contains(autoTasks[i], "动态"):
 		log.Printf("[%s] 执行发送动态任务中", userData.Profile.Nickname)
-		err := sendEventTask(userData, data)
+		err = sendEventTask(userData, data)
+		recordTask("event", err)
 		if err != nil {
 			log.Println(err)
 		}
@@ -323,21 +457,24 @@ func musicianTasks(userData types.LoginStatusData, data utils.RequestData, autoT
 			CommentID:    config.CommentConfig.RepliedComment[processingUser].CommentID,
 			ForwardEvent: false,
 		}
-		err := replyCommentTask(userData, commentConfig, data)
+		err = replyCommentTask(userData, commentConfig, data)
+		recordTask("comment", err)
 		if err != nil {
 			log.Println(err)
 		}
 		log.Printf("[%s] 发送回复评论执行完成", userData.Profile.Nickname)
 	case strings.Contains(autoTasks[i], "私信"):
 		log.Printf("[%s] 执行发送私信任务中", userData.Profile.Nickname)
-		err := sendMsgTask(userData, config.SendMsgConfig.UserID[processingUser], data)
+		err = sendMsgTask(userData, config.SendMsgConfig.UserID[processingUser], data)
+		recordTask("message", err)
 		if err != nil {
 			log.Println(err)
 		}
 		log.Printf("[%s] 发送私信任务执行完成", userData.Profile.Nickname)
 	case strings.Contains(autoTasks[i], "mlog"):
 		log.Printf("[%s] 执行发送 Mlog 任务中", userData.Profile.Nickname)
-		err := sendMlogTask(userData, data)
+		err = sendMlogTask(userData, data)
+		recordTask("mlog", err)
 		if err != nil {
 			log.Println(err)
 		}
@@ -349,23 +486,26 @@ func musicianTasks(userData types.LoginStatusData, data utils.RequestData, autoT
 			ResID:        config.CommentConfig.RepliedComment[processingUser].MusicID,
 			ForwardEvent: false,
 		}
-		err := musicianSaidTask(userData, commentConfig, data)
+		err = musicianSaidTask(userData, commentConfig, data)
+		recordTask("musician_said", err)
 		if err != nil {
 			log.Println(err)
 		}
 		log.Printf("[%s] 发送主创说任务执行完成", userData.Profile.Nickname)
 	case strings.Contains(autoTasks[i], "云圈"):
 		log.Printf("[%s] 执行访问云圈任务中", userData.Profile.Nickname)
-		err := getCircleTask(data)
+		err = getCircleTask(data)
+		recordTask("circle", err)
 		if err != nil {
 			log.Println(err)
 		}
 		log.Printf("[%s] 访问云圈任务执行完成", userData.Profile.Nickname)
 	}
+	return err
 }
 
 func shareMusicTask(userData types.LoginStatusData, data utils.RequestData) error {
-	shareResult, err := api.SongShare(data, config.MusicShareConfig.MySongID)
+	shareResult, err := songShare(userData, data, config.MusicShareConfig.MySongID)
 	if err != nil {
 		return err
 	}
@@ -374,12 +514,13 @@ func shareMusicTask(userData types.LoginStatusData, data utils.RequestData) erro
 	} else {
 		log.Printf("[%s] 分享音乐失败, 原因: %s, 歌曲ID: %d", userData.Profile.Nickname, shareResult.Message, config.MusicShareConfig.MySongID)
 	}
-	sendResult, err := api.ShareResource(data, config.MusicShareConfig.MySongID, "song", "")
+	sendResult, err := shareResource(userData, data, config.MusicShareConfig.MySongID, "song", "")
 	if err != nil {
 		return err
 	}
 	if sendResult.Code == 200 {
 		log.Printf("[%s] 发送歌曲分享动态成功, 动态ID: %d, 歌曲ID: %d", userData.Profile.Nickname, sendResult.Event.Id, config.MusicShareConfig.MySongID)
+		pendingJournal.Add(PendingDeletion{Type: PendingEvent, UserID: userData.Account.Id, ID: sendResult.Event.Id})
 		if config.EventSendConfig.LagConfig.LagBetweenSendAndDelete {
 			randomLag := eventLag.Get()
 			if randomLag != 0 {
@@ -387,7 +528,7 @@ func shareMusicTask(userData types.LoginStatusData, data utils.RequestData) erro
 				time.Sleep(time.Duration(randomLag) * time.Second)
 			}
 		}
-		delResult, err := api.DelEvent(data, sendResult.Event.Id)
+		delResult, err := delEvent(userData, data, sendResult.Event.Id, AuditEventDelete)
 		if err != nil {
 			return err
 		}
@@ -395,6 +536,7 @@ func shareMusicTask(userData types.LoginStatusData, data utils.RequestData) erro
 			log.Errorf("[%s] 删除动态失败, 动态ID: %d, 代码: %d, 原因: \"%s\"", userData.Profile.Nickname, sendResult.Event.Id, delResult.Code, delResult.Message)
 		} else {
 			log.Printf("[%s] 删除动态成功, 动态ID: %d", userData.Profile.Nickname, sendResult.Event.Id)
+			pendingJournal.Remove(PendingEvent, sendResult.Event.Id)
 		}
 	} else {
 		log.Errorf("[%s] 发送歌曲分享动态, 代码: %d, 原因: \"%s\"", userData.Profile.Nickname, sendResult.Code, sendResult.Message)
@@ -402,6 +544,24 @@ func shareMusicTask(userData types.LoginStatusData, data utils.RequestData) erro
 	return nil
 }
 
+// resolveCircleID 重新获取当前用户的云圈 ID, 非音乐人或没有云圈时为空
+func resolveCircleID(userData types.LoginStatusData, data utils.RequestData) error {
+	circleID = ""
+	userDetail, err := api.GetUserDetail(data, userData.Account.Id)
+	if err != nil {
+		return err
+	}
+	if userDetail.Profile.ArtistId == 0 {
+		return nil
+	}
+	artistDetail, err := api.GetArtistHomepage(data, int64(userDetail.Profile.ArtistId))
+	if err != nil {
+		return err
+	}
+	parseCircleID(artistDetail)
+	return nil
+}
+
 func getCircleTask(data utils.RequestData) error {
 	if circleID != "" {
 		result, err := api.GetCircle(data, circleID)
@@ -416,7 +576,7 @@ func getCircleTask(data utils.RequestData) error {
 }
 
 func userSignTask(userData types.LoginStatusData, data utils.RequestData) error {
-	result, err := api.UserSign(data, 0)
+	result, err := userSign(userData, data, 0)
 	if err != nil {
 		return err
 	}
@@ -426,33 +586,40 @@ func userSignTask(userData types.LoginStatusData, data utils.RequestData) error
 		log.Printf("[%s] 签到成功 (%s)", userData.Profile.Nickname, "Android")
 		pushMsg += fmt.Sprintf("\n[%s] 签到成功 (%s)", userData.Profile.Nickname, "Android")
 	}
+	reportAccount().Signs = append(reportAccount().Signs, SignReport{Platform: "Android", Success: result.Code == 200, Message: result.Msg})
 
-	result, err = api.UserSign(data, 1)
+	result, err = userSign(userData, data, 1)
 	if err != nil {
 		return err
 	}
 	if result.Code != 200 {
 		log.Printf("[%s] %s (%s)", userData.Profile.Nickname, result.Msg, "web/PC")
 	} else {
-		log.Printf("[%s] 签到成功 (%s)", userData.Profile.Nickname, "Android")
-		pushMsg += fmt.Sprintf("\n[%s] 签到成功 (%s)", userData.Profile.Nickname, "Android")
+		log.Printf("[%s] 签到成功 (%s)", userData.Profile.Nickname, "web/PC")
+		pushMsg += fmt.Sprintf("\n[%s] 签到成功 (%s)", userData.Profile.Nickname, "web/PC")
 	}
+	reportAccount().Signs = append(reportAccount().Signs, SignReport{Platform: "web/PC", Success: result.Code == 200, Message: result.Msg})
 	return nil
 }
 
 func sendEventTask(userData types.LoginStatusData, data utils.RequestData) error {
+	renderer := newContentRenderer(userData, data)
 	failedTimes := 0
 	for i := 0; i < 1; {
 		if failedTimes >= 5 {
 			return fmt.Errorf("[%s] 发送动态累计 %d 次失败, 已自动退出", userData.Profile.Nickname, failedTimes)
 		}
-		msg := randomText(config.Content)
-		sendResult, err := api.SendEvent(data, msg, []string{})
+		msg, err := renderer.render(0)
+		if err != nil {
+			return err
+		}
+		sendResult, err := sendEvent(userData, data, msg)
 		if err != nil {
 			return err
 		}
 		if sendResult.Code == 200 {
 			log.Printf("[%s] 发送动态成功, 动态ID: %d, 内容: \"%s\"", userData.Profile.Nickname, sendResult.Event.Id, msg)
+			pendingJournal.Add(PendingDeletion{Type: PendingEvent, UserID: userData.Account.Id, ID: sendResult.Event.Id})
 			i++
 			if config.EventSendConfig.LagConfig.LagBetweenSendAndDelete {
 				randomLag := eventLag.Get()
@@ -461,7 +628,7 @@ func sendEventTask(userData types.LoginStatusData, data utils.RequestData) error
 					time.Sleep(time.Duration(randomLag) * time.Second)
 				}
 			}
-			delResult, err := api.DelEvent(data, sendResult.Event.Id)
+			delResult, err := delEvent(userData, data, sendResult.Event.Id, AuditEventDelete)
 			if err != nil {
 				return err
 			}
@@ -469,6 +636,7 @@ func sendEventTask(userData types.LoginStatusData, data utils.RequestData) error
 				log.Errorf("[%s] 删除动态失败, 动态ID: %d, 代码: %d, 原因: \"%s\"", userData.Profile.Nickname, sendResult.Event.Id, delResult.Code, delResult.Message)
 			} else {
 				log.Printf("[%s] 删除动态成功, 动态ID: %d", userData.Profile.Nickname, sendResult.Event.Id)
+				pendingJournal.Remove(PendingEvent, sendResult.Event.Id)
 			}
 		} else {
 			log.Errorf("[%s] 发送动态失败, 内容: \"%s\", 代码: %d, 原因: \"%s\"", userData.Profile.Nickname, msg, sendResult.Code, sendResult.Message)
@@ -485,20 +653,25 @@ func sendEventTask(userData types.LoginStatusData, data utils.RequestData) error
 
 func replyCommentTask(userData types.LoginStatusData, commentConfig api.CommentConfig, data utils.RequestData) error {
 	replyToID := commentConfig.CommentID
+	renderer := newContentRenderer(userData, data)
 	failedTimes := 0
 	for i := 0; i < 2; {
 		if failedTimes >= 5 {
 			return fmt.Errorf("[%s] 回复评论累计 %d 次失败, 已自动退出", userData.Profile.Nickname, failedTimes)
 		}
-		msg := randomText(config.Content)
+		msg, err := renderer.render(commentConfig.ResID)
+		if err != nil {
+			return err
+		}
 		commentConfig.CommentID = replyToID
 		commentConfig.Content = msg
-		replyResult, err := api.ReplyComment(data, commentConfig)
+		replyResult, err := replyComment(userData, data, commentConfig)
 		if err != nil {
 			return err
 		}
 		if replyResult.Code == 200 {
 			log.Printf("[%s] 回复评论成功, 歌曲ID: %d, 评论ID: %d, 内容: \"%s\"", userData.Profile.Nickname, commentConfig.ResID, commentConfig.CommentID, msg)
+			pendingJournal.Add(PendingDeletion{Type: PendingComment, UserID: userData.Account.Id, ID: replyResult.Comment.CommentId, ResType: api.ResTypeMusic, ResID: commentConfig.ResID})
 			i++
 			if config.CommentConfig.LagConfig.LagBetweenSendAndDelete {
 				randomLag := commentLag.Get()
@@ -510,7 +683,7 @@ func replyCommentTask(userData types.LoginStatusData, commentConfig api.CommentC
 			commentConfig.CommentID = replyResult.Comment.CommentId
 			commentConfig.ResType = api.ResTypeMusic
 			commentConfig.Content = ""
-			delResult, err := api.DelComment(data, commentConfig)
+			delResult, err := delComment(userData, data, commentConfig)
 			if err != nil {
 				return err
 			}
@@ -518,6 +691,7 @@ func replyCommentTask(userData types.LoginStatusData, commentConfig api.CommentC
 				log.Errorf("[%s] 删除评论失败, 歌曲ID: %d, 评论ID: %d, 代码: %d", userData.Profile.Nickname, commentConfig.ResID, commentConfig.CommentID, delResult.Code)
 			} else {
 				log.Printf("[%s] 删除评论成功, 歌曲ID: %d, 评论ID: %d", userData.Profile.Nickname, commentConfig.ResID, commentConfig.CommentID)
+				pendingJournal.Remove(PendingComment, commentConfig.CommentID)
 			}
 		} else {
 			log.Errorf("[%s] 回复评论失败, 歌曲ID: %d, 评论ID: %d, 内容: \"%s\", 代码: %d", userData.Profile.Nickname, commentConfig.ResID, commentConfig.CommentID, msg, replyResult.Code)
@@ -533,6 +707,7 @@ func replyCommentTask(userData types.LoginStatusData, commentConfig api.CommentC
 }
 
 func sendMsgTask(userData types.LoginStatusData, userIDs []int, data utils.RequestData) error {
+	renderer := newContentRenderer(userData, data)
 	failedTimes := 0
 	for i := 0; i < 2; {
 		if failedTimes >= 5 {
@@ -545,8 +720,11 @@ func sendMsgTask(userData types.LoginStatusData, userIDs []int, data utils.Reque
 			rand.Seed(time.Now().UnixNano())
 			userID = userIDs[rand.Intn(len(userIDs)-1)]
 		}
-		msg := randomText(config.Content)
-		sendResult, err := api.SendTextMsg(data, []int{userID}, msg)
+		msg, err := renderer.render(0)
+		if err != nil {
+			return err
+		}
+		sendResult, err := sendTextMsg(userData, data, []int{userID}, msg)
 		if err != nil {
 			return err
 		}
@@ -584,22 +762,26 @@ func sendMlogTask(userData types.LoginStatusData, data utils.RequestData) error
 	rand.Seed(time.Now().UnixNano())
 	fileName := files[rand.Intn(len(files))].Name()
 	musicID := config.SendMlogConfig.MusicIDs[rand.Intn(len(config.SendMlogConfig.MusicIDs))]
-	text := randomText(config.Content)
-	mlogData, err := api.SendPicMlog(data, text, musicID, []string{fmt.Sprintf("%s/%s", config.SendMlogConfig.PicFolder, fileName)})
+	text, err := renderContent(userData, data, musicID)
+	if err != nil {
+		return err
+	}
+	mlogData, err := sendPicMlog(userData, data, text, musicID, []string{fmt.Sprintf("%s/%s", config.SendMlogConfig.PicFolder, fileName)})
 	if err != nil {
 		return err
 	}
 	if mlogData.Code != 200 {
 		log.Errorf("[%s] 发送 Mlog 失败, 代码: %d, 原因: \"%s\"", userData.Profile.Nickname, mlogData.Code, mlogData.Message)
-	} else {
-		log.Printf("[%s] 发送 Mlog 成功, 动态ID: %d, 内容: \"%s\", 图片: \"%s\"", userData.Profile.Nickname, mlogData.Data.Event.Id, text, fmt.Sprintf("%s/%s", config.SendMlogConfig.PicFolder, fileName))
+		return nil
 	}
+	log.Printf("[%s] 发送 Mlog 成功, 动态ID: %d, 内容: \"%s\", 图片: \"%s\"", userData.Profile.Nickname, mlogData.Data.Event.Id, text, fmt.Sprintf("%s/%s", config.SendMlogConfig.PicFolder, fileName))
+	pendingJournal.Add(PendingDeletion{Type: PendingMlog, UserID: userData.Account.Id, ID: mlogData.Data.Event.Id})
 	randomLag := mlogLag.Get()
 	if randomLag != 0 {
 		log.Printf("[%s] 延时 %d 秒", userData.Profile.Nickname, randomLag)
 		time.Sleep(time.Duration(randomLag) * time.Second)
 	}
-	result, err := api.DelEvent(data, mlogData.Data.Event.Id)
+	result, err := delEvent(userData, data, mlogData.Data.Event.Id, AuditMlogDelete)
 	if err != nil {
 		return err
 	}
@@ -607,19 +789,24 @@ func sendMlogTask(userData types.LoginStatusData, data utils.RequestData) error
 		log.Errorf("[%s] 删除 Mlog 失败, 动态ID: %d, 代码: %d, 原因: \"%s\"", userData.Profile.Nickname, mlogData.Data.Event.Id, result.Code, result.Message)
 	} else {
 		log.Printf("[%s] 删除 Mlog 成功, 动态ID: %d", userData.Profile.Nickname, mlogData.Data.Event.Id)
+		pendingJournal.Remove(PendingMlog, mlogData.Data.Event.Id)
 	}
 	return nil
 }
 
 func musicianSaidTask(userData types.LoginStatusData, commentConfig api.CommentConfig, data utils.RequestData) error {
-	msg := randomText(config.Content)
+	msg, err := renderContent(userData, data, commentConfig.ResID)
+	if err != nil {
+		return err
+	}
 	commentConfig.Content = msg
-	replyResult, err := api.AddComment(data, commentConfig)
+	replyResult, err := addComment(userData, data, commentConfig)
 	if err != nil {
 		return err
 	}
 	if replyResult.Code == 200 {
 		log.Printf("[%s] 发送评论成功, 歌曲ID: %d, 评论ID: %d, 内容: \"%s\"", userData.Profile.Nickname, commentConfig.ResID, commentConfig.CommentID, msg)
+		pendingJournal.Add(PendingDeletion{Type: PendingComment, UserID: userData.Account.Id, ID: replyResult.Comment.CommentId, ResType: api.ResTypeMusic, ResID: commentConfig.ResID})
 		if config.CommentConfig.LagConfig.LagBetweenSendAndDelete {
 			randomLag := commentLag.Get()
 			if randomLag != 0 {
@@ -630,7 +817,7 @@ func musicianSaidTask(userData types.LoginStatusData, commentConfig api.CommentC
 		commentConfig.CommentID = replyResult.Comment.CommentId
 		commentConfig.ResType = api.ResTypeMusic
 		commentConfig.Content = ""
-		delResult, err := api.DelComment(data, commentConfig)
+		delResult, err := delComment(userData, data, commentConfig)
 		if err != nil {
 			return err
 		}
@@ -638,6 +825,7 @@ func musicianSaidTask(userData types.LoginStatusData, commentConfig api.CommentC
 			log.Errorf("[%s] 删除评论失败, 歌曲ID: %d, 评论ID: %d, 代码: %d", userData.Profile.Nickname, commentConfig.ResID, commentConfig.CommentID, delResult.Code)
 		} else {
 			log.Printf("[%s] 删除评论成功, 歌曲ID: %d, 评论ID: %d", userData.Profile.Nickname, commentConfig.ResID, commentConfig.CommentID)
+			pendingJournal.Remove(PendingComment, commentConfig.CommentID)
 		}
 	} else {
 		log.Errorf("[%s] 发送评论失败, 歌曲ID: %d, 评论ID: %d, 内容: \"%s\", 代码: %d", userData.Profile.Nickname, commentConfig.ResID, commentConfig.CommentID, msg, replyResult.Code)
@@ -656,7 +844,7 @@ func vipGrowthpointTask(userData types.LoginStatusData, data utils.RequestData)
 		return nil
 	}
 	log.Printf("[%s] 检查成功，正在领取会员任务成长值", userData.Profile.Nickname)
-	_, err = api.VipTaskRewardAll(data)
+	_, err = vipTaskRewardAll(userData, data)
 	return err
 }
 
@@ -666,6 +854,9 @@ func checkCloudBean(userData types.LoginStatusData, data utils.RequestData) ([]s
 		return nil, err
 	}
 	log.Printf("[%s] 账号当前云豆数: %d", userData.Profile.Nickname, cloudBeanData.Data.CloudBean)
+	metrics.Set("f163_cloud_bean", float64(cloudBeanData.Data.CloudBean), "user", strconv.Itoa(processingUser))
+	setCloudBeanStatus(cloudBeanData.Data.CloudBean)
+	reportCloudBean(cloudBeanData.Data.CloudBean)
 	pushMsg += fmt.Sprintf("\n[%s] 当前云豆数: %d", userData.Profile.Nickname, cloudBeanData.Data.CloudBean)
 	log.Printf("[%s] 获取音乐人任务中...", userData.Profile.Nickname)
 	dailyTasks, err := api.GetMusicianDailyTasks(data)
@@ -678,17 +869,20 @@ func checkCloudBean(userData types.LoginStatusData, data utils.RequestData) ([]s
 	}
 	var isObtainCloudBean bool
 	var autoTasks []string
+	var missions []MissionStatus
 	for _, task := range dailyTasks.Data.List {
+		missions = append(missions, MissionStatus{Description: task.Description, Status: task.Status, Reward: task.RewardWorth})
 		if task.Status == 20 {
 			log.Printf("[%s] 「%s」任务已完成, 正在领取云豆", userData.Profile.Nickname, task.Description)
 			isObtainCloudBean = true
-			result, err := api.ObtainCloudbean(data, task.UserMissionId, task.Period)
+			result, err := obtainCloudbean(userData, data, task.UserMissionId, task.Period)
 			if err != nil {
 				log.Errorln(err)
 			}
 			if result.Code == 200 {
 				log.Printf("[%s] 领取「%s」任务云豆成功, 云豆+%s", userData.Profile.Nickname, task.Description, task.RewardWorth)
 				pushMsg += fmt.Sprintf("\n[%s] 完成「%s」任务云豆+%s", userData.Profile.Nickname, task.Description, task.RewardWorth)
+				reportAccount().Missions = append(reportAccount().Missions, MissionReport{Description: task.Description, Reward: task.RewardWorth})
 			} else {
 				log.Errorf("[%s] 领取「%s」任务云豆失败: %s", userData.Profile.Nickname, task.Description, result.Message)
 			}
@@ -698,18 +892,20 @@ func checkCloudBean(userData types.LoginStatusData, data utils.RequestData) ([]s
 		}
 	}
 	for _, task := range weeklyTasks.Data.List {
+		missions = append(missions, MissionStatus{Description: task.Description, Weekly: true, Status: task.Status})
 		for _, s := range task.UserStageTargetList {
 			if s.Status == 20 {
 				log.Printf("[%s] 「%s」任务已完成, 正在领取云豆", userData.Profile.Nickname, task.Description)
 				isObtainCloudBean = true
 				if s.UserMissionId != 0 {
-					result, err := api.ObtainCloudbean(data, int(s.UserMissionId), task.Period)
+					result, err := obtainCloudbean(userData, data, int(s.UserMissionId), task.Period)
 					if err != nil {
 						log.Errorln(err)
 					}
 					if result.Code == 200 {
 						log.Printf("[%s] 领取「%s」任务云豆成功, 云豆+%d", userData.Profile.Nickname, task.Description, s.Worth)
 						pushMsg += fmt.Sprintf("\n[%s] 完成「%s」任务云豆+%d", userData.Profile.Nickname, task.Description, s.Worth)
+						reportAccount().Missions = append(reportAccount().Missions, MissionReport{Description: task.Description, Reward: strconv.Itoa(s.Worth)})
 					} else {
 						log.Errorf("[%s] 领取「%s」任务云豆失败: %s", userData.Profile.Nickname, task.Description, result.Message)
 					}
@@ -727,18 +923,17 @@ func checkCloudBean(userData types.LoginStatusData, data utils.RequestData) ([]s
 			return nil, err
 		}
 		log.Printf("[%s] 账号当前云豆数: %d", userData.Profile.Nickname, cloudBeanData.Data.CloudBean)
+		metrics.Set("f163_cloud_bean", float64(cloudBeanData.Data.CloudBean), "user", strconv.Itoa(processingUser))
+		setCloudBeanStatus(cloudBeanData.Data.CloudBean)
+		reportCloudBean(cloudBeanData.Data.CloudBean)
 	}
 	if len(autoTasks) == 0 {
 		log.Printf("[%s] 后面的任务, 明天再来探索吧！", userData.Profile.Nickname)
 	}
+	setMissionStatus(autoTasks, missions)
 	return autoTasks, err
 }
 
-func randomText(textSlice []string) string {
-	rand.Seed(time.Now().UnixNano())
-	return textSlice[rand.Intn(len(textSlice)-1)]
-}
-
 func checkPathExists(path string) bool {
 	_, err := os.Stat(path)
 	if err == nil {
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metrics Prometheus 指标 (文本格式), 仅实现本程序用到的 counter/gauge/histogram
+type Metrics struct {
+	sync.Mutex
+	families map[string]*metricFamily
+}
+
+type metricFamily struct {
+	Name    string
+	Help    string
+	Type    string
+	Buckets []float64
+	Series  map[string]*metricSeries
+}
+
+type metricSeries struct {
+	Labels string
+	Value  float64
+	Counts []uint64
+	Sum    float64
+	Count  uint64
+}
+
+var metrics = newMetrics()
+
+var apiLatencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+func newMetrics() *Metrics {
+	m := &Metrics{families: map[string]*metricFamily{}}
+	m.register("f163_runs_total", "counter", "Total number of task runs.", nil)
+	m.register("f163_runs_failed_total", "counter", "Total number of task runs with at least one failed account.", nil)
+	m.register("f163_task_total", "counter", "Task executions by user, task and result.", nil)
+	m.register("f163_api_request_duration_seconds", "histogram", "Latency of outgoing NetEase API requests by endpoint.", apiLatencyBuckets)
+	m.register("f163_cloud_bean", "gauge", "Current cloud bean balance by user.", nil)
+	m.register("f163_cookie_valid", "gauge", "Whether the configured cookie of a user is valid (1) or not (0).", nil)
+	m.register("f163_next_run_timestamp_seconds", "gauge", "Unix timestamp of the next scheduled cron run.", nil)
+	return m
+}
+
+func (m *Metrics) register(name, metricType, help string, buckets []float64) {
+	m.families[name] = &metricFamily{
+		Name:    name,
+		Help:    help,
+		Type:    metricType,
+		Buckets: buckets,
+		Series:  map[string]*metricSeries{},
+	}
+}
+
+// series 返回指定标签的序列, 调用前需持有锁. labels 为 key, value 交替排列
+func (m *Metrics) series(name string, labels []string) *metricSeries {
+	family, ok := m.families[name]
+	if !ok {
+		panic(fmt.Sprintf("metric %s not registered", name))
+	}
+	var pairs []string
+	for i := 0; i+1 < len(labels); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", labels[i], strconv.Quote(labels[i+1])))
+	}
+	key := strings.Join(pairs, ",")
+	s, ok := family.Series[key]
+	if !ok {
+		s = &metricSeries{Labels: key, Counts: make([]uint64, len(family.Buckets))}
+		family.Series[key] = s
+	}
+	return s
+}
+
+// Inc counter 加一
+func (m *Metrics) Inc(name string, labels ...string) {
+	m.Lock()
+	defer m.Unlock()
+	m.series(name, labels).Value++
+}
+
+// Set 设置 gauge
+func (m *Metrics) Set(name string, value float64, labels ...string) {
+	m.Lock()
+	defer m.Unlock()
+	m.series(name, labels).Value = value
+}
+
+// Observe 记录 histogram 观测值
+func (m *Metrics) Observe(name string, value float64, labels ...string) {
+	m.Lock()
+	defer m.Unlock()
+	s := m.series(name, labels)
+	for i, bucket := range m.families[name].Buckets {
+		if value <= bucket {
+			s.Counts[i]++
+		}
+	}
+	s.Sum += value
+	s.Count++
+}
+
+// WriteTo 以 Prometheus 文本格式输出所有指标
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.Lock()
+	defer m.Unlock()
+	var b strings.Builder
+	var names []string
+	for name := range m.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		family := m.families[name]
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, family.Help, name, family.Type)
+		var keys []string
+		for key := range family.Series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			s := family.Series[key]
+			if family.Type != "histogram" {
+				fmt.Fprintf(&b, "%s%s %s\n", name, braceLabels(s.Labels), formatFloat(s.Value))
+				continue
+			}
+			for i, bucket := range family.Buckets {
+				fmt.Fprintf(&b, "%s_bucket%s %d\n", name, braceLabels(joinLabels(s.Labels, "le="+strconv.Quote(formatFloat(bucket)))), s.Counts[i])
+			}
+			fmt.Fprintf(&b, "%s_bucket%s %d\n", name, braceLabels(joinLabels(s.Labels, `le="+Inf"`)), s.Count)
+			fmt.Fprintf(&b, "%s_sum%s %s\n", name, braceLabels(s.Labels), formatFloat(s.Sum))
+			fmt.Fprintf(&b, "%s_count%s %d\n", name, braceLabels(s.Labels), s.Count)
+		}
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// ServeHTTP 输出指标
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = m.WriteTo(w)
+}
+
+func braceLabels(labels string) string {
+	if labels == "" {
+		return ""
+	}
+	return "{" + labels + "}"
+}
+
+func joinLabels(labels, extra string) string {
+	if labels == "" {
+		return extra
+	}
+	return labels + "," + extra
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// 所有指标的 user 标签均为配置文件中 Users 的序号
+
+// recordTask 记录当前用户 (processingUser) 的任务执行结果
+func recordTask(task string, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	metrics.Inc("f163_task_total", "user", strconv.Itoa(processingUser), "task", task, "result", result)
+}
+
+// instrumentedTransport 统计网易云音乐 API 请求的耗时, 推送等其他请求不做统计, 避免 URL 中的 Token 出现在指标中
+type instrumentedTransport struct {
+	next http.RoundTripper
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isNeteaseHost(req.URL.Hostname()) {
+		return t.next.RoundTrip(req)
+	}
+	start := time.Now()
+	res, err := t.next.RoundTrip(req)
+	metrics.Observe("f163_api_request_duration_seconds", time.Since(start).Seconds(), "endpoint", apiEndpoint(req.URL.Path))
+	return res, err
+}
+
+// apiEndpoint 将请求路径转换为固定的接口名: 去掉 /api, /eapi, /weapi 前缀, 数字部分替换为 {id}.
+// 如 /eapi/v1/user/detail/123456 转换为 /v1/user/detail/{id}
+func apiEndpoint(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	switch parts[0] {
+	case "api", "eapi", "weapi":
+		parts = parts[1:]
+	}
+	for i, part := range parts {
+		if part != "" && strings.Trim(part, "0123456789") == "" {
+			parts[i] = "{id}"
+		}
+	}
+	return "/" + strings.Join(parts, "/")
+}
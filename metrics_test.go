@@ -0,0 +1,23 @@
+package main
+
+import "testing"
+
+func TestAPIEndpoint(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/api/w/nuser/account/get", "/w/nuser/account/get"},
+		{"/eapi/login/token/refresh", "/login/token/refresh"},
+		{"/weapi/v1/resource/comments/R_SO_4_123456", "/v1/resource/comments/R_SO_4_123456"},
+		{"/api/v1/user/detail/123456", "/v1/user/detail/{id}"},
+		{"/api/artist/head/info/get/", "/artist/head/info/get"},
+		{"/nos-bucket/obj/20220101", "/nos-bucket/obj/{id}"},
+		{"/", "/"},
+	}
+	for _, tt := range tests {
+		if got := apiEndpoint(tt.path); got != tt.want {
+			t.Errorf("apiEndpoint(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
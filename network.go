@@ -0,0 +1,392 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/XiaoMengXinX/Music163Api-Go/api"
+	"github.com/XiaoMengXinX/Music163Api-Go/types"
+	"github.com/XiaoMengXinX/Music163Api-Go/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DeviceAndroid 安卓客户端
+	DeviceAndroid = "android"
+	// DeviceIOS iOS 客户端
+	DeviceIOS = "ios"
+	// DevicePC PC 客户端
+	DevicePC = "pc"
+	// DeviceWeb 网页版
+	DeviceWeb = "web"
+)
+
+// DeviceConfig 账号使用的设备信息
+type DeviceConfig struct {
+	Profile   string `json:"Profile"`   // 设备类型: android / ios / pc / web
+	UserAgent string `json:"UserAgent"` // 自定义 User-Agent, 为空时使用设备类型的默认值
+	DeviceID  string `json:"DeviceID"`  // 设备 ID, 为空时自动生成并按用户 ID 保存
+}
+
+// accountCookieName 标记请求所属账号 (config.Users 中的序号) 的内部 Cookie, 发出请求前由 accountTransport 移除
+const accountCookieName = "__f163_user"
+
+// deviceProfile 设备类型对应的 User-Agent 及请求 Cookie, Cookie 值为空时从请求中移除
+type deviceProfile struct {
+	UserAgent string
+	Cookies   map[string]string
+}
+
+// deviceProfiles 各设备类型的默认设置
+var deviceProfiles = map[string]deviceProfile{
+	DeviceAndroid: {
+		UserAgent: "NeteaseMusic/8.9.70.1680000000(8009070);Dalvik/2.1.0 (Linux; U; Android 12; Pixel 6 Build/SQ3A.220705.003)",
+		Cookies: map[string]string{
+			"os":          "android",
+			"osver":       "12",
+			"appver":      "8.9.70",
+			"versioncode": "8009070",
+			"mobilename":  "Pixel 6",
+			"resolution":  "2400x1080",
+			"channel":     "netease",
+		},
+	},
+	DeviceIOS: {
+		UserAgent: "NeteaseMusic 8.9.70/3870 (iPhone; iOS 16.2; zh_CN)",
+		Cookies: map[string]string{
+			"os":          "iPhone OS",
+			"osver":       "16.2",
+			"appver":      "8.9.70",
+			"versioncode": "",
+			"mobilename":  "iPhone14,5",
+			"resolution":  "2532x1170",
+			"channel":     "distribution",
+		},
+	},
+	DevicePC: {
+		UserAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.164 NeteaseMusicDesktop/2.10.6.200601",
+		Cookies: map[string]string{
+			"os":          "pc",
+			"osver":       "Microsoft-Windows-10-Professional-build-19045-64bit",
+			"appver":      "2.10.6.200601",
+			"versioncode": "",
+			"resolution":  "1920x1080",
+			"channel":     "netease",
+		},
+	},
+	DeviceWeb: {
+		UserAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/118.0.0.0 Safari/537.36",
+		Cookies: map[string]string{
+			"os":          "pc",
+			"appver":      "",
+			"versioncode": "",
+			"buildver":    "",
+			"resolution":  "",
+		},
+	},
+}
+
+// accountIdentity 账号的网络身份
+type accountIdentity struct {
+	Proxy        *url.URL
+	UserAgent    string
+	Cookies      map[string]string
+	AutoDeviceID bool // 未设置 DeviceID, 每次请求时按用户 ID 取自动生成的设备 ID
+}
+
+// accountTransport 按请求中的账号标记 (accountCookieName) 为账号的请求设置代理及设备信息.
+// 有账号设置了代理时, 没有账号标记的网易云音乐请求不会直接发出, 避免暴露本机 IP
+type accountTransport struct {
+	sync.Mutex
+	next       http.RoundTripper
+	base       *http.Transport
+	identities []*accountIdentity
+	proxied    bool
+	proxies    map[string]http.RoundTripper
+}
+
+// setupAccountNetwork 读取各账号的代理及设备设置, 并替换默认的 http.Transport
+func setupAccountNetwork() {
+	t := &accountTransport{next: http.DefaultTransport, proxies: map[string]http.RoundTripper{}}
+	if base, ok := http.DefaultTransport.(*http.Transport); ok {
+		t.base = base
+	} else {
+		t.base = &http.Transport{}
+	}
+	for i, user := range config.Users {
+		identity, err := newAccountIdentity(user.Proxy, user.Device)
+		if err != nil {
+			log.Fatalf("User[%d] 的网络设置错误: %v", i, err)
+		}
+		t.identities = append(t.identities, identity)
+		if identity != nil && identity.Proxy != nil {
+			t.proxied = true
+		}
+	}
+	http.DefaultTransport = t // 始终替换, 确保账号标记不会被发送出去
+}
+
+// newAccountIdentity 根据配置生成账号的网络身份, 未设置代理及设备时返回 nil
+func newAccountIdentity(proxy string, device DeviceConfig) (*accountIdentity, error) {
+	if proxy == "" && device.Profile == "" && device.UserAgent == "" && device.DeviceID == "" {
+		return nil, nil
+	}
+	identity := &accountIdentity{Cookies: map[string]string{}}
+	if proxy != "" {
+		u, err := url.Parse(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("代理地址格式错误: %v", err)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, fmt.Errorf("不支持的代理类型 \"%s\", 可选 http / https / socks5", u.Scheme)
+		}
+		identity.Proxy = u
+	}
+	if device.Profile == "" && device.UserAgent == "" && device.DeviceID == "" {
+		return identity, nil
+	}
+	profile, ok := deviceProfiles[strings.ToLower(device.Profile)]
+	if device.Profile != "" && !ok {
+		return nil, fmt.Errorf("未知的设备类型 \"%s\", 可选 android / ios / pc / web", device.Profile)
+	}
+	identity.UserAgent = profile.UserAgent
+	if device.UserAgent != "" {
+		identity.UserAgent = device.UserAgent
+	}
+	for k, v := range profile.Cookies {
+		identity.Cookies[k] = v
+	}
+	if device.DeviceID != "" {
+		identity.Cookies["deviceId"] = device.DeviceID
+	} else {
+		identity.AutoDeviceID = true
+	}
+	return identity, nil
+}
+
+// accountCookies 返回账号 (config.Users 中的序号) 请求时使用的 Cookie, 并加上账号标记
+func accountCookies(user int, cookies []*http.Cookie) []*http.Cookie {
+	return append(requestCookies(cookies), &http.Cookie{Name: accountCookieName, Value: strconv.Itoa(user)})
+}
+
+// accountRequestData 返回账号 (config.Users 中的序号) 的请求数据
+func accountRequestData(user int) utils.RequestData {
+	return utils.RequestData{Cookies: accountCookies(user, config.Users[user].Cookies)}
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (t *accountTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	user, marked := requestUser(req) // 上传文件的节点可能是 IP 地址, 因此带有账号标记的请求不论域名都经过账号设置
+	if !marked {
+		if t.proxied && isNeteaseHost(req.URL.Hostname()) {
+			return nil, fmt.Errorf("请求 %s 未标记所属账号, 已设置账号代理时不会直接发出", req.URL.Host)
+		}
+		return t.next.RoundTrip(req) // 推送等其他请求不经过账号代理
+	}
+	if user < 0 || user >= len(t.identities) {
+		return nil, fmt.Errorf("请求 %s 标记的账号 User[%d] 不存在", req.URL.Host, user)
+	}
+	identity := t.identities[user]
+	cookies := map[string]string{accountCookieName: ""}
+	req = req.Clone(req.Context())
+	if identity == nil {
+		req.Header.Set("Cookie", rewriteCookieHeader(req.Header.Get("Cookie"), cookies))
+		return t.next.RoundTrip(req)
+	}
+	for k, v := range identity.Cookies {
+		cookies[k] = v
+	}
+	if identity.AutoDeviceID {
+		cookies["deviceId"] = deviceIDs.Get(user)
+	}
+	if identity.UserAgent != "" {
+		req.Header.Set("User-Agent", identity.UserAgent)
+	}
+	req.Header.Set("Cookie", rewriteCookieHeader(req.Header.Get("Cookie"), cookies))
+	if identity.Proxy == nil {
+		return t.next.RoundTrip(req)
+	}
+	return t.proxy(identity.Proxy).RoundTrip(req)
+}
+
+// isNeteaseHost 是否为网易云音乐的域名
+func isNeteaseHost(host string) bool {
+	return strings.HasSuffix(host, ".163.com") || strings.HasSuffix(host, ".126.net") || strings.HasSuffix(host, ".127.net")
+}
+
+// proxy 返回使用该代理的 http.Transport, 同一个代理复用连接
+func (t *accountTransport) proxy(u *url.URL) http.RoundTripper {
+	t.Lock()
+	defer t.Unlock()
+	key := u.String()
+	rt, ok := t.proxies[key]
+	if !ok {
+		transport := t.base.Clone()
+		transport.Proxy = http.ProxyURL(u)
+		rt = transport
+		t.proxies[key] = rt
+	}
+	return rt
+}
+
+// requestUser 返回请求 Cookie 中标记的账号序号, 没有标记时 marked 为 false
+func requestUser(req *http.Request) (user int, marked bool) {
+	for _, c := range req.Cookies() {
+		if c.Name == accountCookieName {
+			user, err := strconv.Atoi(c.Value)
+			if err != nil {
+				return -1, true
+			}
+			return user, true
+		}
+	}
+	return -1, false
+}
+
+// rewriteCookieHeader 按 cookies 替换请求 Cookie 中的值, 值为空时移除该 Cookie
+func rewriteCookieHeader(header string, cookies map[string]string) string {
+	var parts []string
+	seen := map[string]bool{}
+	for _, part := range strings.Split(header, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name := part
+		if i := strings.Index(part, "="); i >= 0 {
+			name = part[:i]
+		}
+		if value, ok := cookies[name]; ok {
+			seen[name] = true
+			if value == "" {
+				continue
+			}
+			part = name + "=" + escapeCookieValue(value)
+		}
+		parts = append(parts, part)
+	}
+	var names []string
+	for name, value := range cookies {
+		if !seen[name] && value != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		parts = append(parts, name+"="+escapeCookieValue(cookies[name]))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// escapeCookieValue 与 Music163Api-Go 相同的 Cookie 编码方式
+func escapeCookieValue(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// uploadNodeURL 获取上传加速节点的地址, 与 api.GetUploadNode 相同
+const uploadNodeURL = "https://wanproxy.127.net/lbs?version=1.0&bucketname=cloudmusic"
+
+// resolveUploadNode 以账号的网络设置获取上传加速节点.
+// api.UploadFile 自行获取节点时请求不带账号标记, 设置了账号代理时会被拒绝
+func resolveUploadNode(data utils.RequestData) error {
+	if api.UploadNode != "" {
+		return nil
+	}
+	var marker []*http.Cookie
+	for _, c := range data.Cookies {
+		if c.Name == accountCookieName {
+			marker = append(marker, c)
+		}
+	}
+	resBody, err := utils.RawRequest(uploadNodeURL, utils.RequestData{Cookies: marker})
+	if err != nil {
+		return err
+	}
+	var result types.UploadNodeData
+	if err = json.Unmarshal([]byte(resBody), &result); err != nil {
+		return fmt.Errorf("解析上传节点失败: %v", err)
+	}
+	if len(result.Upload) == 0 {
+		return fmt.Errorf("获取上传节点失败")
+	}
+	api.UploadNode = result.Upload[0]
+	return nil
+}
+
+// newDeviceID 生成随机的设备 ID
+func newDeviceID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return strings.ToUpper(hex.EncodeToString(b))
+}
+
+// DeviceIDs 自动生成的设备 ID, 按用户 ID 保存, 调整账号顺序或更换 Cookie 后同一用户仍使用同一设备
+type DeviceIDs struct {
+	sync.Mutex
+	File    string
+	IDs     map[string]string
+	pending map[int]string
+}
+
+var deviceIDs DeviceIDs
+
+// Load 从文件读取设备 ID
+func (d *DeviceIDs) Load(file string) error {
+	d.Lock()
+	defer d.Unlock()
+	d.File = file
+	d.IDs = map[string]string{}
+	d.pending = map[int]string{}
+	return loadJSON(file, &d.IDs)
+}
+
+// Get 返回账号 (config.Users 中的序号) 使用的设备 ID.
+// 账号状态中记录的用户 ID 对应当前 Cookie 时使用该用户的设备 ID, 不存在时生成并保存;
+// 否则 (如首次登录或更换了 Cookie) 使用本次运行的临时设备 ID, 确认用户 ID 后作为该用户的设备 ID
+func (d *DeviceIDs) Get(user int) string {
+	state := accountStates.Get(user)
+	confirmed := state.UserID != 0 && state.CookieHash == cookieHash(config.Users[user].Cookies)
+	d.Lock()
+	defer d.Unlock()
+	if d.IDs == nil {
+		d.IDs = map[string]string{}
+	}
+	if d.pending == nil {
+		d.pending = map[int]string{}
+	}
+	if !confirmed {
+		if d.pending[user] == "" {
+			d.pending[user] = newDeviceID()
+		}
+		return d.pending[user]
+	}
+	key := strconv.Itoa(state.UserID)
+	if id, ok := d.IDs[key]; ok {
+		return id
+	}
+	id := d.pending[user]
+	if id == "" {
+		id = state.DeviceID // 旧版本按账号序号保存的设备 ID
+	}
+	if id == "" {
+		id = newDeviceID()
+	}
+	delete(d.pending, user)
+	d.IDs[key] = id
+	if d.File != "" {
+		if err := saveJSON(d.File, d.IDs); err != nil {
+			log.Errorf("写入设备 ID 失败: %v", err)
+		}
+	}
+	return id
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Notification 推送消息内容
+type Notification struct {
+	Title   string
+	Content string
+	Report  *RunReport
+	Alert   bool // 即时提醒, 不受推送级别、每日汇总及去重策略影响
+
+	Recipients []string       // 只发送给这些接收者 (如 Telegram 的 chat id), 为空时发送给全部, 用于重试部分发送失败的推送
+	Delivered  map[string]int // 超长消息拆分为多条时, 各接收者已发送成功的条数, 重试时跳过这些消息
+}
+
+// Notifier 推送后端
+type Notifier interface {
+	// Name 推送后端名称, 用于日志
+	Name() string
+	// Send 发送推送消息
+	Send(msg Notification) error
+}
+
+// NotifierConfig 推送后端配置
+type NotifierConfig struct {
+	Type    string          `json:"Type"`
+	Name    string          `json:"Name"`
+	Options json.RawMessage `json:"Options"`
+	Policy  NotifyPolicy    `json:"Policy"`
+}
+
+// NotifierFactory 根据配置创建推送后端
+type NotifierFactory func(name string, options json.RawMessage) (Notifier, error)
+
+var notifierFactories = map[string]NotifierFactory{}
+
+// notifyClient 推送使用的 HTTP Client
+var notifyClient = &http.Client{Timeout: 15 * time.Second}
+
+// registerNotifier 注册推送后端类型
+func registerNotifier(notifierType string, factory NotifierFactory) {
+	notifierFactories[strings.ToLower(notifierType)] = factory
+}
+
+// notifierConfigs 返回所有推送后端配置, 兼容旧版的 PushPlusToken 及 ServerSendKey
+func notifierConfigs() []NotifierConfig {
+	configs := append([]NotifierConfig{}, config.Notifiers...)
+	if config.PushPlusToken != "" {
+		options, _ := json.Marshal(map[string]string{"Token": config.PushPlusToken})
+		configs = append(configs, NotifierConfig{Type: "pushplus", Options: options})
+	}
+	if config.ServerSendKey != "" {
+		options, _ := json.Marshal(map[string]string{"SendKey": config.ServerSendKey})
+		configs = append(configs, NotifierConfig{Type: "serverchan", Options: options})
+	}
+	return configs
+}
+
+// loadNotifiers 根据配置创建所有推送后端
+func loadNotifiers() []Notifier {
+	var notifiers []Notifier
+	for i, c := range notifierConfigs() {
+		factory, ok := notifierFactories[strings.ToLower(c.Type)]
+		if !ok {
+			log.Errorf("Notifiers[%d]: 未知的推送类型 \"%s\"", i, c.Type)
+			continue
+		}
+		name := c.Name
+		if name == "" {
+			name = c.Type
+		}
+		options := c.Options
+		if len(options) == 0 {
+			options = json.RawMessage("{}")
+		}
+		notifier, err := factory(name, options)
+		if err == nil {
+			err = c.Policy.validate()
+		}
+		if err != nil {
+			log.Errorf("Notifiers[%d]: 创建 %s 推送失败: %v", i, name, err)
+			continue
+		}
+		key := notifierKey(name, c)
+		notifyState.Migrate(name, key)
+		notifiers = append(notifiers, &PolicyNotifier{
+			Notifier: &outboxNotifier{Notifier: notifier, key: key},
+			Policy:   c.Policy,
+			key:      key,
+		})
+	}
+	return notifiers
+}
+
+// sendNotification 通过所有推送后端发送消息, 返回各推送后端的错误
+func sendNotification(notifiers []Notifier, msg Notification) map[string]error {
+	msg.Title = redactor.Redact(msg.Title)
+	msg.Content = redactor.Redact(msg.Content)
+	errs := map[string]error{}
+	for _, n := range notifiers {
+		err := n.Send(msg)
+		var skipped skippedError
+		if errors.As(err, &skipped) {
+			log.Printf("[%s] 跳过推送: %v", n.Name(), err)
+			continue
+		}
+		if err != nil {
+			log.Errorf("[%s] 推送失败: %v", n.Name(), err)
+			errs[n.Name()] = err
+			continue
+		}
+		log.Printf("[%s] 推送成功", n.Name())
+	}
+	return errs
+}
+
+// registerNotifierSecrets 将推送配置中的 Token/Key/Secret/Password 添加为敏感信息
+func registerNotifierSecrets() {
+	for _, c := range config.Notifiers {
+		var options map[string]interface{}
+		if json.Unmarshal(c.Options, &options) != nil {
+			continue
+		}
+		collectSecrets(options)
+	}
+}
+
+func collectSecrets(options map[string]interface{}) {
+	var keys []string
+	for k := range options {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		switch v := options[k].(type) {
+		case string:
+			lower := strings.ToLower(k)
+			if strings.Contains(lower, "token") || strings.Contains(lower, "key") || strings.Contains(lower, "secret") || strings.Contains(lower, "password") {
+				redactor.AddSecrets(v)
+			}
+		case map[string]interface{}:
+			collectSecrets(v)
+		}
+	}
+}
+
+// postJSON 发送 JSON 请求, 并将返回的 JSON 解析到 result
+func postJSON(url string, body interface{}, result interface{}) error {
+	reqBody, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	return doNotifyRequest(http.MethodPost, url, "application/json", bytes.NewReader(reqBody), nil, result)
+}
+
+// postForm 发送表单请求, 并将返回的 JSON 解析到 result
+func postForm(url string, data url.Values, result interface{}) error {
+	return doNotifyRequest(http.MethodPost, url, "application/x-www-form-urlencoded", strings.NewReader(data.Encode()), nil, result)
+}
+
+// doNotifyRequest 发送推送请求, 返回非 2xx 状态码时视为失败
+func doNotifyRequest(method, url, contentType string, body io.Reader, headers map[string]string, result interface{}) error {
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	res, err := notifyClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	resBody, err := ioutil.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("%s: %s", res.Status, strings.TrimSpace(string(resBody)))
+	}
+	if result != nil && len(resBody) != 0 {
+		if err := json.Unmarshal(resBody, result); err != nil {
+			return fmt.Errorf("解析返回内容失败: %v, 返回内容: %s", err, resBody)
+		}
+	}
+	return nil
+}
+
+// accountMessages 按账号拆分推送, 每个账号一条消息, 没有运行结果或为即时提醒时返回原消息
+func accountMessages(msg Notification) []Notification {
+	if msg.Alert || msg.Report == nil || len(msg.Report.Accounts) == 0 {
+		return []Notification{msg}
+	}
+	var messages []Notification
+	for _, a := range msg.Report.Accounts {
+		messages = append(messages, Notification{
+			Title:   redactor.Redact(msg.Title + " - " + a.Name()),
+			Content: redactor.Redact(a.Summary()),
+			Report:  &RunReport{StartTime: msg.Report.StartTime, EndTime: msg.Report.EndTime, Accounts: []*AccountReport{a}},
+		})
+	}
+	return messages
+}
+
+// sendAccountMessages 按账号拆分并逐条发送, 部分账号发送成功时返回 partialError, 重试时只发送剩余的账号
+func sendAccountMessages(msg Notification, send func(Notification) error) error {
+	for i, m := range accountMessages(msg) {
+		err := send(m)
+		if err == nil {
+			continue
+		}
+		if i == 0 {
+			return err
+		}
+		remaining := msg
+		remaining.Report = &RunReport{StartTime: msg.Report.StartTime, EndTime: msg.Report.EndTime, Accounts: msg.Report.Accounts[i:]}
+		return partialError{err: err, remaining: remaining}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// barkDefaultLevels 运行结果对应的 Bark 通知级别
+var barkDefaultLevels = map[string]string{
+	OutcomeSuccess:       "passive",
+	OutcomeWarning:       "active",
+	OutcomeFailure:       "active",
+	OutcomeCookieExpired: "timeSensitive",
+}
+
+// BarkNotifier Bark 推送
+type BarkNotifier struct {
+	name           string
+	Server         string            `json:"Server"`
+	DeviceKey      string            `json:"DeviceKey"`
+	Group          string            `json:"Group"`
+	GroupByAccount bool              `json:"GroupByAccount"`
+	Levels         map[string]string `json:"Levels"`
+	Sound          string            `json:"Sound"`
+	Icon           string            `json:"Icon"`
+}
+
+func init() {
+	registerNotifier("bark", newBarkNotifier)
+}
+
+func newBarkNotifier(name string, options json.RawMessage) (Notifier, error) {
+	n := &BarkNotifier{name: name}
+	if err := json.Unmarshal(options, n); err != nil {
+		return nil, err
+	}
+	if n.DeviceKey == "" {
+		return nil, fmt.Errorf("DeviceKey 不能为空")
+	}
+	if n.Server == "" {
+		n.Server = "https://api.day.app"
+	}
+	n.Server = strings.TrimRight(n.Server, "/")
+	if n.Group == "" {
+		n.Group = "Fuck163MusicTasks"
+	}
+	n.Levels = mergeLevels(barkDefaultLevels, n.Levels)
+	return n, nil
+}
+
+// Name 实现 Notifier
+func (n *BarkNotifier) Name() string {
+	return n.name
+}
+
+// Send 实现 Notifier
+func (n *BarkNotifier) Send(msg Notification) error {
+	if n.GroupByAccount {
+		return sendAccountMessages(msg, n.send)
+	}
+	return n.send(msg)
+}
+
+// send 发送一条消息
+func (n *BarkNotifier) send(m Notification) error {
+	group := n.Group
+	if n.GroupByAccount && m.Report != nil && len(m.Report.Accounts) == 1 {
+		group = m.Report.Accounts[0].Name()
+	}
+	message := map[string]string{
+		"device_key": n.DeviceKey,
+		"title":      m.Title,
+		"body":       m.Content,
+		"group":      group,
+		"level":      n.Levels[m.Report.Outcome()],
+	}
+	if n.Sound != "" {
+		message["sound"] = n.Sound
+	}
+	if n.Icon != "" {
+		message["icon"] = n.Icon
+	}
+	var result struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	err := postJSON(n.Server+"/push", message, &result)
+	if err != nil {
+		return err
+	}
+	if result.Code != 200 {
+		return fmt.Errorf("代码: %d, 原因: \"%s\"", result.Code, result.Message)
+	}
+	return nil
+}
+
+// mergeLevels 使用配置覆盖默认的运行结果与优先级对应关系
+func mergeLevels(defaults, levels map[string]string) map[string]string {
+	merged := map[string]string{}
+	for k, v := range defaults {
+		merged[k] = v
+	}
+	for k, v := range levels {
+		merged[k] = v
+	}
+	return merged
+}
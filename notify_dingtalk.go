@@ -0,0 +1,98 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DingTalkNotifier 钉钉自定义机器人推送
+type DingTalkNotifier struct {
+	name        string
+	AccessToken string   `json:"AccessToken"`
+	Secret      string   `json:"Secret"`
+	AtMobiles   []string `json:"AtMobiles"`
+	AtAll       bool     `json:"AtAll"`
+	URL         string   `json:"URL"`
+}
+
+func init() {
+	registerNotifier("dingtalk", newDingTalkNotifier)
+}
+
+func newDingTalkNotifier(name string, options json.RawMessage) (Notifier, error) {
+	n := &DingTalkNotifier{name: name}
+	if err := json.Unmarshal(options, n); err != nil {
+		return nil, err
+	}
+	if n.AccessToken == "" && n.URL == "" {
+		return nil, fmt.Errorf("AccessToken 不能为空")
+	}
+	if n.URL == "" {
+		n.URL = "https://oapi.dingtalk.com/robot/send?access_token=" + url.QueryEscape(n.AccessToken)
+	}
+	return n, nil
+}
+
+// Name 实现 Notifier
+func (n *DingTalkNotifier) Name() string {
+	return n.name
+}
+
+// Send 实现 Notifier
+func (n *DingTalkNotifier) Send(msg Notification) error {
+	text := "#### " + msg.Title + "\n\n" + strings.ReplaceAll(msg.Content, "\n", "\n\n")
+	for _, mobile := range n.AtMobiles {
+		text += " @" + mobile
+	}
+	message := map[string]interface{}{
+		"msgtype": "markdown",
+		"markdown": map[string]string{
+			"title": msg.Title,
+			"text":  text,
+		},
+		"at": map[string]interface{}{
+			"atMobiles": n.AtMobiles,
+			"isAtAll":   n.AtAll,
+		},
+	}
+	var result struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	err := postJSON(n.signedURL(), message, &result)
+	if err != nil {
+		return err
+	}
+	if result.ErrCode != 0 {
+		return fmt.Errorf("代码: %d, 原因: \"%s\"", result.ErrCode, result.ErrMsg)
+	}
+	return nil
+}
+
+// signedURL 启用加签时在 URL 中添加 timestamp 及 sign
+func (n *DingTalkNotifier) signedURL() string {
+	if n.Secret == "" {
+		return n.URL
+	}
+	timestamp := strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10)
+	sign := dingTalkSign(timestamp, n.Secret)
+	sep := "?"
+	if strings.Contains(n.URL, "?") {
+		sep = "&"
+	}
+	return n.URL + sep + "timestamp=" + timestamp + "&sign=" + url.QueryEscape(sign)
+}
+
+// dingTalkSign 钉钉加签, 以 secret 为密钥计算 timestamp + "\n" + secret 的 HmacSHA256
+func dingTalkSign(timestamp, secret string) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(timestamp + "\n" + secret))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
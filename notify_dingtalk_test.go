@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestDingTalkSign(t *testing.T) {
+	tests := []struct {
+		timestamp, secret, want string
+	}{
+		{"1700000000000", "SEC0123456789abcdef", "TSZbRFUuvaSQaRKUpF970OPCb2/LcQAP3wOvwZIzBZk="},
+		{"1600000000000", "secret", "XHSnLTbboLLBCrXfAQRHx6W9LkLB43RYwgcsOS2j3vs="},
+	}
+	for _, tt := range tests {
+		if got := dingTalkSign(tt.timestamp, tt.secret); got != tt.want {
+			t.Errorf("dingTalkSign(%q, %q) = %q, want %q", tt.timestamp, tt.secret, got, tt.want)
+		}
+	}
+}
+
+func TestDingTalkSignedURL(t *testing.T) {
+	n := &DingTalkNotifier{URL: "https://oapi.dingtalk.com/robot/send?access_token=abc"}
+	if got := n.signedURL(); got != n.URL {
+		t.Errorf("未设置 Secret 时不应修改 URL: %q", got)
+	}
+	n.Secret = "SEC0123456789abcdef"
+	u, err := url.Parse(n.signedURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if q.Get("access_token") != "abc" || q.Get("timestamp") == "" {
+		t.Fatalf("签名后的 URL 缺少参数: %s", u)
+	}
+	if want := dingTalkSign(q.Get("timestamp"), n.Secret); q.Get("sign") != want {
+		t.Errorf("sign = %q, want %q", q.Get("sign"), want)
+	}
+	if strings.Contains(u.RawQuery, "+") || strings.Contains(u.RawQuery, "/") {
+		t.Errorf("sign 未经过 URL 编码: %s", u.RawQuery)
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// emailTemplate 邮件 HTML 报告模板
+var emailTemplate = template.Must(template.New("email").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head><meta charset="utf-8"><title>{{.Title}}</title></head>
+<body style="font-family: -apple-system, 'Segoe UI', 'PingFang SC', 'Microsoft YaHei', sans-serif; color: #333;">
+<h2 style="color: #c20c0c;">{{.Title}}</h2>
+{{- with .Report}}
+<p>运行时间: {{.StartTime.Format "2006-01-02 15:04:05"}}{{if not .EndTime.IsZero}} ~ {{.EndTime.Format "15:04:05"}}{{end}}</p>
+<table style="border-collapse: collapse; font-size: 14px;" cellpadding="6">
+<tr style="background: #f5f5f5;"><th align="left">账号</th><th align="left">Cookie</th><th align="left">签到</th><th align="left">音乐人任务</th><th align="left">云豆</th><th align="left">错误</th></tr>
+{{- range .Accounts}}
+<tr style="border-top: 1px solid #eee;" valign="top">
+<td>User[{{.User}}]{{if .Nickname}}<br>{{.Nickname}}{{end}}{{if .UserID}}<br><small>{{.UserID}}</small>{{end}}</td>
+<td>{{if .CookieValid}}<span style="color: #23862f;">有效</span>{{else}}<span style="color: #c20c0c;">已失效</span>{{end}}</td>
+<td>{{range .Signs}}{{.Platform}}: {{if .Success}}<span style="color: #23862f;">成功</span>{{else}}<span style="color: #c20c0c;">{{.Message}}</span>{{end}}<br>{{else}}-{{end}}</td>
+<td>{{range .Missions}}{{.Description}} (+{{.Reward}})<br>{{else}}-{{end}}</td>
+<td>{{if .BeanKnown}}{{.BeanAfter}} ({{if ge .BeanDelta 0}}+{{end}}{{.BeanDelta}}){{else}}-{{end}}</td>
+<td>{{range .Errors}}<span style="color: #c20c0c;">{{.}}</span><br>{{end}}{{range .Warnings}}<span style="color: #d48806;">{{.}}</span><br>{{end}}{{if not (or .Errors .Warnings)}}-{{end}}</td>
+</tr>
+{{- end}}
+</table>
+{{- end}}
+<pre style="background: #f5f5f5; padding: 12px; white-space: pre-wrap;">{{.Content}}</pre>
+</body>
+</html>
+`))
+
+// EmailNotifier SMTP 邮件推送
+type EmailNotifier struct {
+	name     string
+	Host     string   `json:"Host"`
+	Port     int      `json:"Port"`
+	Security string   `json:"Security"`
+	Username string   `json:"Username"`
+	Password string   `json:"Password"`
+	From     string   `json:"From"`
+	To       []string `json:"To"`
+	Insecure bool     `json:"InsecureSkipVerify"`
+}
+
+func init() {
+	registerNotifier("email", newEmailNotifier)
+}
+
+func newEmailNotifier(name string, options json.RawMessage) (Notifier, error) {
+	n := &EmailNotifier{name: name}
+	if err := json.Unmarshal(options, n); err != nil {
+		return nil, err
+	}
+	if n.Host == "" {
+		return nil, fmt.Errorf("Host 不能为空")
+	}
+	if len(n.To) == 0 {
+		return nil, fmt.Errorf("To 不能为空")
+	}
+	if n.From == "" {
+		n.From = n.Username
+	}
+	if n.From == "" {
+		return nil, fmt.Errorf("From 不能为空")
+	}
+	n.Security = strings.ToLower(n.Security)
+	switch n.Security {
+	case "", "starttls":
+		n.Security = "starttls"
+		if n.Port == 0 {
+			n.Port = 587
+		}
+	case "tls", "ssl":
+		n.Security = "tls"
+		if n.Port == 0 {
+			n.Port = 465
+		}
+	case "none":
+		if n.Port == 0 {
+			n.Port = 25
+		}
+	default:
+		return nil, fmt.Errorf("不支持的 Security \"%s\", 可选 starttls, tls 或 none", n.Security)
+	}
+	return n, nil
+}
+
+// Name 实现 Notifier
+func (n *EmailNotifier) Name() string {
+	return n.name
+}
+
+// Send 实现 Notifier
+func (n *EmailNotifier) Send(msg Notification) error {
+	body, err := n.message(msg)
+	if err != nil {
+		return err
+	}
+	c, err := n.dial()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if n.Username != "" {
+		err = c.Auth(smtp.PlainAuth("", n.Username, n.Password, n.Host))
+		if err != nil {
+			return fmt.Errorf("SMTP 认证失败: %v", err)
+		}
+	}
+	if err = c.Mail(n.From); err != nil {
+		return err
+	}
+	for _, to := range n.To {
+		if err = c.Rcpt(to); err != nil {
+			return fmt.Errorf("收件人 %s: %v", to, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(body); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// dial 按 Security 连接 SMTP 服务器
+func (n *EmailNotifier) dial() (*smtp.Client, error) {
+	addr := net.JoinHostPort(n.Host, strconv.Itoa(n.Port))
+	tlsConfig := &tls.Config{ServerName: n.Host, InsecureSkipVerify: n.Insecure}
+	dialer := &net.Dialer{Timeout: notifyClient.Timeout}
+	var conn net.Conn
+	var err error
+	if n.Security == "tls" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Now().Add(2 * notifyClient.Timeout))
+	c, err := smtp.NewClient(conn, n.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if n.Security == "starttls" {
+		if err = c.StartTLS(tlsConfig); err != nil {
+			c.Close()
+			return nil, fmt.Errorf("STARTTLS 失败: %v", err)
+		}
+	}
+	return c, nil
+}
+
+// message 生成包含纯文本及 HTML 报告的邮件
+func (n *EmailNotifier) message(msg Notification) ([]byte, error) {
+	var html bytes.Buffer
+	err := emailTemplate.Execute(&html, msg)
+	if err != nil {
+		return nil, fmt.Errorf("生成邮件内容失败: %v", err)
+	}
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	boundary := "f163-" + hex.EncodeToString(b)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", n.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(n.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", msg.Title))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=\"%s\"\r\n\r\n", boundary)
+	writeMIMEPart(&buf, boundary, "text/plain", []byte(msg.Content))
+	writeMIMEPart(&buf, boundary, "text/html", []byte(redactor.Redact(html.String())))
+	fmt.Fprintf(&buf, "--%s--\r\n", boundary)
+	return buf.Bytes(), nil
+}
+
+// writeMIMEPart 写入 base64 编码的 MIME 段
+func writeMIMEPart(buf *bytes.Buffer, boundary, contentType string, content []byte) {
+	fmt.Fprintf(buf, "--%s\r\n", boundary)
+	fmt.Fprintf(buf, "Content-Type: %s; charset=UTF-8\r\n", contentType)
+	buf.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+	encoded := base64.StdEncoding.EncodeToString(content)
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded + "\r\n")
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// FeishuNotifier 飞书/Lark 自定义机器人推送, 以消息卡片格式发送
+type FeishuNotifier struct {
+	name   string
+	Token  string `json:"Token"`
+	Secret string `json:"Secret"`
+	Lark   bool   `json:"Lark"`
+	URL    string `json:"URL"`
+}
+
+func init() {
+	registerNotifier("feishu", newFeishuNotifier)
+	registerNotifier("lark", func(name string, options json.RawMessage) (Notifier, error) {
+		return createFeishuNotifier(&FeishuNotifier{name: name, Lark: true}, options)
+	})
+}
+
+func newFeishuNotifier(name string, options json.RawMessage) (Notifier, error) {
+	return createFeishuNotifier(&FeishuNotifier{name: name}, options)
+}
+
+func createFeishuNotifier(n *FeishuNotifier, options json.RawMessage) (Notifier, error) {
+	if err := json.Unmarshal(options, n); err != nil {
+		return nil, err
+	}
+	if n.Token == "" && n.URL == "" {
+		return nil, fmt.Errorf("Token 不能为空")
+	}
+	if n.URL == "" {
+		if n.Lark {
+			n.URL = "https://open.larksuite.com/open-apis/bot/v2/hook/" + n.Token
+		} else {
+			n.URL = "https://open.feishu.cn/open-apis/bot/v2/hook/" + n.Token
+		}
+	}
+	return n, nil
+}
+
+// Name 实现 Notifier
+func (n *FeishuNotifier) Name() string {
+	return n.name
+}
+
+// Send 实现 Notifier
+func (n *FeishuNotifier) Send(msg Notification) error {
+	color := "green"
+	if msg.Report != nil && msg.Report.Failed() {
+		color = "red"
+	}
+	message := map[string]interface{}{
+		"msg_type": "interactive",
+		"card": map[string]interface{}{
+			"config": map[string]bool{"wide_screen_mode": true},
+			"header": map[string]interface{}{
+				"template": color,
+				"title":    map[string]string{"tag": "plain_text", "content": msg.Title},
+			},
+			"elements": []interface{}{
+				map[string]interface{}{
+					"tag":  "div",
+					"text": map[string]string{"tag": "lark_md", "content": msg.Content},
+				},
+			},
+		},
+	}
+	if n.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		message["timestamp"] = timestamp
+		message["sign"] = feishuSign(timestamp, n.Secret)
+	}
+	var result struct {
+		Code       int    `json:"code"`
+		Msg        string `json:"msg"`
+		StatusCode int    `json:"StatusCode"`
+	}
+	err := postJSON(n.URL, message, &result)
+	if err != nil {
+		return err
+	}
+	if result.Code != 0 {
+		return fmt.Errorf("代码: %d, 原因: \"%s\"", result.Code, result.Msg)
+	}
+	return nil
+}
+
+// feishuSign 飞书签名校验, 以 timestamp + "\n" + secret 为密钥计算空字符串的 HmacSHA256
+func feishuSign(timestamp, secret string) string {
+	h := hmac.New(sha256.New, []byte(timestamp+"\n"+secret))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,17 @@
+package main
+
+import "testing"
+
+func TestFeishuSign(t *testing.T) {
+	tests := []struct {
+		timestamp, secret, want string
+	}{
+		{"1700000000", "feishusecret", "5Wc8F8b2vZlNjoVFpK6BAaovMobglP4Rm1FzmEq/rCU="},
+		{"1599360473", "demo", "l1N0gAcBjdwBvGm1xMjOF0XSyaLRpR7tuO5dHfhAYc8="},
+	}
+	for _, tt := range tests {
+		if got := feishuSign(tt.timestamp, tt.secret); got != tt.want {
+			t.Errorf("feishuSign(%q, %q) = %q, want %q", tt.timestamp, tt.secret, got, tt.want)
+		}
+	}
+}
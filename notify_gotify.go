@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// gotifyDefaultPriorities 运行结果对应的 Gotify 优先级 (0-10)
+var gotifyDefaultPriorities = map[string]int{
+	OutcomeSuccess:       2,
+	OutcomeWarning:       4,
+	OutcomeFailure:       5,
+	OutcomeCookieExpired: 8,
+}
+
+// GotifyNotifier Gotify 推送
+type GotifyNotifier struct {
+	name           string
+	Server         string         `json:"Server"`
+	Token          string         `json:"Token"`
+	GroupByAccount bool           `json:"GroupByAccount"`
+	Priorities     map[string]int `json:"Priorities"`
+}
+
+func init() {
+	registerNotifier("gotify", newGotifyNotifier)
+}
+
+func newGotifyNotifier(name string, options json.RawMessage) (Notifier, error) {
+	n := &GotifyNotifier{name: name}
+	if err := json.Unmarshal(options, n); err != nil {
+		return nil, err
+	}
+	if n.Server == "" {
+		return nil, fmt.Errorf("Server 不能为空")
+	}
+	if n.Token == "" {
+		return nil, fmt.Errorf("Token 不能为空")
+	}
+	n.Server = strings.TrimRight(n.Server, "/")
+	n.Priorities = mergePriorities(gotifyDefaultPriorities, n.Priorities)
+	return n, nil
+}
+
+// Name 实现 Notifier
+func (n *GotifyNotifier) Name() string {
+	return n.name
+}
+
+// Send 实现 Notifier
+func (n *GotifyNotifier) Send(msg Notification) error {
+	if n.GroupByAccount {
+		return sendAccountMessages(msg, n.send)
+	}
+	return n.send(msg)
+}
+
+// send 发送一条消息
+func (n *GotifyNotifier) send(m Notification) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"title":    m.Title,
+		"message":  m.Content,
+		"priority": n.Priorities[m.Report.Outcome()],
+	})
+	if err != nil {
+		return err
+	}
+	return doNotifyRequest(http.MethodPost, n.Server+"/message", "application/json", bytes.NewReader(body), map[string]string{"X-Gotify-Key": n.Token}, nil)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ntfyDefaultPriorities 运行结果对应的 ntfy 优先级 (1-5)
+var ntfyDefaultPriorities = map[string]int{
+	OutcomeSuccess:       2,
+	OutcomeWarning:       3,
+	OutcomeFailure:       4,
+	OutcomeCookieExpired: 5,
+}
+
+// ntfyTags 运行结果对应的 ntfy 标签 (显示为 emoji)
+var ntfyTags = map[string]string{
+	OutcomeSuccess:       "white_check_mark",
+	OutcomeWarning:       "hourglass_flowing_sand",
+	OutcomeFailure:       "warning",
+	OutcomeCookieExpired: "rotating_light",
+}
+
+// NtfyNotifier ntfy 推送
+type NtfyNotifier struct {
+	name           string
+	Server         string         `json:"Server"`
+	Topic          string         `json:"Topic"`
+	Token          string         `json:"Token"`
+	Username       string         `json:"Username"`
+	Password       string         `json:"Password"`
+	GroupByAccount bool           `json:"GroupByAccount"`
+	Priorities     map[string]int `json:"Priorities"`
+}
+
+func init() {
+	registerNotifier("ntfy", newNtfyNotifier)
+}
+
+func newNtfyNotifier(name string, options json.RawMessage) (Notifier, error) {
+	n := &NtfyNotifier{name: name}
+	if err := json.Unmarshal(options, n); err != nil {
+		return nil, err
+	}
+	if n.Topic == "" {
+		return nil, fmt.Errorf("Topic 不能为空")
+	}
+	if n.Server == "" {
+		n.Server = "https://ntfy.sh"
+	}
+	n.Server = strings.TrimRight(n.Server, "/")
+	n.Priorities = mergePriorities(ntfyDefaultPriorities, n.Priorities)
+	return n, nil
+}
+
+// Name 实现 Notifier
+func (n *NtfyNotifier) Name() string {
+	return n.name
+}
+
+// Send 实现 Notifier
+func (n *NtfyNotifier) Send(msg Notification) error {
+	if n.GroupByAccount {
+		return sendAccountMessages(msg, n.send)
+	}
+	return n.send(msg)
+}
+
+// send 发送一条消息
+func (n *NtfyNotifier) send(m Notification) error {
+	headers := map[string]string{}
+	if n.Token != "" {
+		headers["Authorization"] = "Bearer " + n.Token
+	} else if n.Username != "" {
+		headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(n.Username+":"+n.Password))
+	}
+	outcome := m.Report.Outcome()
+	tags := []string{ntfyTags[outcome]}
+	if n.GroupByAccount && m.Report != nil && len(m.Report.Accounts) == 1 {
+		tags = append(tags, fmt.Sprintf("user%d", m.Report.Accounts[0].User))
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"topic":    n.Topic,
+		"title":    m.Title,
+		"message":  m.Content,
+		"priority": n.Priorities[outcome],
+		"tags":     tags,
+	})
+	if err != nil {
+		return err
+	}
+	return doNotifyRequest(http.MethodPost, n.Server, "application/json", bytes.NewReader(body), headers, nil)
+}
+
+// mergePriorities 使用配置覆盖默认的运行结果与优先级对应关系
+func mergePriorities(defaults, priorities map[string]int) map[string]int {
+	merged := map[string]int{}
+	for k, v := range defaults {
+		merged[k] = v
+	}
+	for k, v := range priorities {
+		merged[k] = v
+	}
+	return merged
+}
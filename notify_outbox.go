@@ -0,0 +1,244 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// outboxMinBackoff 第一次重试的间隔
+	outboxMinBackoff = time.Minute
+	// outboxMaxBackoff 重试间隔的上限
+	outboxMaxBackoff = time.Hour
+	// outboxDefaultMaxAge 默认最长重试时间
+	outboxDefaultMaxAge = 24 * time.Hour
+)
+
+// OutboxEntry 等待发送或重试的推送
+type OutboxEntry struct {
+	ID          string       `json:"ID"`
+	Notifier    string       `json:"Notifier"`
+	Name        string       `json:"Name"`
+	Message     Notification `json:"Message"`
+	CreatedAt   time.Time    `json:"CreatedAt"`
+	NextAttempt time.Time    `json:"NextAttempt"`
+	Attempts    int          `json:"Attempts"`
+	LastError   string       `json:"LastError,omitempty"`
+}
+
+// Outbox 推送发件箱, 推送前写入, 发送成功或超过最长重试时间后移除
+type Outbox struct {
+	sync.Mutex
+	File    string
+	Entries []*OutboxEntry
+}
+
+var outbox Outbox
+
+// Load 从文件读取发件箱
+func (o *Outbox) Load(file string) error {
+	o.Lock()
+	defer o.Unlock()
+	o.File = file
+	o.Entries = nil
+	return loadJSON(file, &o.Entries)
+}
+
+// save 写入文件, 调用前需持有锁
+func (o *Outbox) save() {
+	if o.File == "" {
+		return
+	}
+	if err := saveJSON(o.File, o.Entries); err != nil {
+		log.Errorf("写入推送发件箱失败: %v", err)
+	}
+}
+
+// Add 写入一条待发送的推送
+func (o *Outbox) Add(key, name string, msg Notification) string {
+	o.Lock()
+	defer o.Unlock()
+	now := time.Now()
+	entry := &OutboxEntry{
+		ID:          strconv.FormatInt(now.UnixNano(), 36),
+		Notifier:    key,
+		Name:        name,
+		Message:     msg,
+		CreatedAt:   now,
+		NextAttempt: now.Add(outboxMinBackoff),
+	}
+	o.Entries = append(o.Entries, entry)
+	o.save()
+	return entry.ID
+}
+
+// Remove 移除一条推送
+func (o *Outbox) Remove(id string) {
+	o.Lock()
+	defer o.Unlock()
+	for i, e := range o.Entries {
+		if e.ID == id {
+			o.Entries = append(o.Entries[:i], o.Entries[i+1:]...)
+			o.save()
+			return
+		}
+	}
+}
+
+// Failed 记录一次发送失败, 并按指数退避计算下次重试时间. 部分发送成功时只保留需要重试的部分
+func (o *Outbox) Failed(id string, err error) {
+	o.Lock()
+	defer o.Unlock()
+	for _, e := range o.Entries {
+		if e.ID == id {
+			var partial partialError
+			if errors.As(err, &partial) {
+				e.Message = partial.remaining
+			}
+			e.Attempts++
+			e.LastError = redactor.Redact(err.Error())
+			backoff := outboxMinBackoff << uint(e.Attempts-1)
+			if backoff > outboxMaxBackoff || backoff <= 0 {
+				backoff = outboxMaxBackoff
+			}
+			e.NextAttempt = time.Now().Add(backoff)
+			o.save()
+			return
+		}
+	}
+}
+
+// Len 发件箱中的推送数量
+func (o *Outbox) Len() int {
+	o.Lock()
+	defer o.Unlock()
+	return len(o.Entries)
+}
+
+// Due 移除超过 maxAge 的推送, 返回被移除的推送及需要重试的推送, force 为 true 时忽略重试间隔
+func (o *Outbox) Due(maxAge time.Duration, force bool) (expired, due []OutboxEntry) {
+	o.Lock()
+	defer o.Unlock()
+	now := time.Now()
+	var entries []*OutboxEntry
+	for _, e := range o.Entries {
+		if now.Sub(e.CreatedAt) > maxAge {
+			expired = append(expired, *e)
+			continue
+		}
+		entries = append(entries, e)
+		if force || !now.Before(e.NextAttempt) {
+			due = append(due, *e)
+		}
+	}
+	if len(expired) != 0 {
+		o.Entries = entries
+		o.save()
+	}
+	return expired, due
+}
+
+// queuedError 推送失败, 已写入发件箱等待重试
+type queuedError struct {
+	err error
+}
+
+func (e queuedError) Error() string {
+	return fmt.Sprintf("%v, 已加入重试队列", e.err)
+}
+
+// partialError 推送只有部分发送成功, remaining 为需要重试的部分, 避免重试时重复发送给已成功的接收者
+type partialError struct {
+	err       error
+	remaining Notification
+}
+
+func (e partialError) Error() string {
+	return fmt.Sprintf("部分推送失败: %v", e.err)
+}
+
+// outboxNotifier 发送前将推送写入发件箱的推送后端
+type outboxNotifier struct {
+	Notifier
+	key string
+}
+
+// Send 实现 Notifier
+func (n *outboxNotifier) Send(msg Notification) error {
+	id := outbox.Add(n.key, n.Name(), msg)
+	err := n.Notifier.Send(msg)
+	if err != nil {
+		outbox.Failed(id, err)
+		return queuedError{err}
+	}
+	outbox.Remove(id)
+	return nil
+}
+
+// notifierKey 推送后端的唯一标识, 用于重试时找到对应的推送后端, 以及区分各推送后端的去重及汇总状态
+func notifierKey(name string, c NotifierConfig) string {
+	sum := sha256.Sum256([]byte(c.Type + "\n" + string(c.Options)))
+	return name + "#" + hex.EncodeToString(sum[:6])
+}
+
+// outboxMaxAge 推送的最长重试时间
+func outboxMaxAge() time.Duration {
+	if config.Outbox.MaxAge > 0 {
+		return time.Duration(config.Outbox.MaxAge) * time.Second
+	}
+	return outboxDefaultMaxAge
+}
+
+// retryOutbox 重试发件箱中的推送, force 为 true 时忽略重试间隔
+func retryOutbox(notifiers []Notifier, force bool) {
+	backends := map[string]Notifier{}
+	for _, n := range notifiers {
+		if p, ok := n.(*PolicyNotifier); ok {
+			if o, ok := p.Notifier.(*outboxNotifier); ok {
+				backends[o.key] = o.Notifier
+			}
+		}
+	}
+	expired, due := outbox.Due(outboxMaxAge(), force)
+	for _, e := range expired {
+		log.Errorf("[%s] 推送在 %s 内未能发送成功, 已放弃: %s", e.Name, outboxMaxAge(), e.Message.Title)
+	}
+	for _, e := range due {
+		backend, ok := backends[e.Notifier]
+		if !ok {
+			log.Warnf("[%s] 推送配置已不存在, 放弃重试: %s", e.Name, e.Message.Title)
+			outbox.Remove(e.ID)
+			continue
+		}
+		err := backend.Send(e.Message)
+		if err != nil {
+			outbox.Failed(e.ID, err)
+			log.Errorf("[%s] 第 %d 次重试推送失败: %v", e.Name, e.Attempts+1, err)
+			continue
+		}
+		outbox.Remove(e.ID)
+		log.Printf("[%s] 重试推送成功: %s", e.Name, e.Message.Title)
+	}
+}
+
+// startOutboxRetry 守护进程模式下在后台定时重试发件箱中的推送
+func startOutboxRetry() {
+	notifiers := loadNotifiers()
+	if len(notifiers) == 0 {
+		return
+	}
+	go func() {
+		for range time.Tick(time.Minute) {
+			if outbox.Len() != 0 {
+				retryOutbox(notifiers, false)
+			}
+		}
+	}()
+}
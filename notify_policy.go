@@ -0,0 +1,326 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// severityOrder 运行结果的严重程度
+var severityOrder = map[string]int{
+	OutcomeSuccess:       0,
+	OutcomeWarning:       1,
+	OutcomeFailure:       2,
+	OutcomeCookieExpired: 3,
+}
+
+// NotifyPolicy 推送策略
+type NotifyPolicy struct {
+	MinSeverity string `json:"MinSeverity"` // 最低推送级别: success (默认, 每次都推送), warning, failure, cookie_expired
+	Accounts    []int  `json:"Accounts"`    // 只推送这些账号 (config.Users 中的序号), 为空时推送全部账号
+	Digest      bool   `json:"Digest"`      // 每日汇总, 每天 DigestAt 之后的第一次运行时推送此前的所有运行结果 (包括本次)
+	DigestAt    string `json:"DigestAt"`    // 每日汇总的推送时间, 格式为 15:04, 默认 00:00
+	DedupWindow int    `json:"DedupWindow"` // 相同内容的推送在此时间内 (秒) 不再重复发送
+}
+
+// validate 检查推送策略
+func (p NotifyPolicy) validate() error {
+	if _, ok := severityOrder[p.MinSeverity]; !ok && p.MinSeverity != "" {
+		return fmt.Errorf("未知的 MinSeverity \"%s\", 可选 success, warning, failure 或 cookie_expired", p.MinSeverity)
+	}
+	for _, user := range p.Accounts {
+		if user < 0 || user >= len(config.Users) {
+			return fmt.Errorf("Accounts 中的 User[%d] 不存在", user)
+		}
+	}
+	if p.DigestAt != "" {
+		if _, err := time.Parse("15:04", p.DigestAt); err != nil {
+			return fmt.Errorf("DigestAt \"%s\" 格式错误, 应为 15:04 格式", p.DigestAt)
+		}
+	}
+	return nil
+}
+
+// digestCutoff 返回 now 之前最近一次的每日汇总推送时间 at (15:04 格式, 为空时为 00:00)
+func digestCutoff(now time.Time, at string) time.Time {
+	var hour, min int
+	if t, err := time.Parse("15:04", at); err == nil {
+		hour, min = t.Hour(), t.Minute()
+	}
+	cutoff := time.Date(now.Year(), now.Month(), now.Day(), hour, min, 0, 0, now.Location())
+	if now.Before(cutoff) {
+		cutoff = cutoff.AddDate(0, 0, -1)
+	}
+	return cutoff
+}
+
+// PolicyNotifier 按推送策略过滤消息的推送后端
+type PolicyNotifier struct {
+	Notifier
+	Policy NotifyPolicy
+	key    string // 去重及汇总状态的标识, 同 notifierKey
+}
+
+// skippedError 消息被推送策略跳过
+type skippedError struct {
+	reason string
+}
+
+func (e skippedError) Error() string {
+	return e.reason
+}
+
+// Send 实现 Notifier
+func (n *PolicyNotifier) Send(msg Notification) error {
+	msg, ok := n.filterAccounts(msg)
+	if !ok {
+		return skippedError{"没有需要推送的账号"}
+	}
+	if msg.Alert {
+		return n.Notifier.Send(msg)
+	}
+	outcome := msg.Report.Outcome()
+	if severityOrder[outcome] < severityOrder[n.Policy.MinSeverity] {
+		return skippedError{fmt.Sprintf("运行结果 %s 低于推送级别 %s", outcome, n.Policy.MinSeverity)}
+	}
+	if n.Policy.Digest {
+		return n.sendDigest(msg)
+	}
+	window := time.Duration(n.Policy.DedupWindow) * time.Second
+	if window > 0 && notifyState.Duplicate(n.key, messageHash(msg), window) {
+		return skippedError{"相同内容已在去重时间内推送过"}
+	}
+	err := n.Notifier.Send(msg)
+	if sentOrQueued(err) {
+		notifyState.Sent(n.key, messageHash(msg))
+	}
+	return err
+}
+
+// sentOrQueued 推送已发送成功, 或已写入发件箱等待重试
+func sentOrQueued(err error) bool {
+	var queued queuedError
+	return err == nil || errors.As(err, &queued)
+}
+
+// filterAccounts 只保留策略中指定的账号, 没有剩余账号时返回 false
+func (n *PolicyNotifier) filterAccounts(msg Notification) (Notification, bool) {
+	if len(n.Policy.Accounts) == 0 || msg.Report == nil {
+		return msg, true
+	}
+	report := &RunReport{StartTime: msg.Report.StartTime, EndTime: msg.Report.EndTime}
+	var lines []string
+	for _, a := range msg.Report.Accounts {
+		for _, user := range n.Policy.Accounts {
+			if a.User == user {
+				report.Accounts = append(report.Accounts, a)
+				lines = append(lines, fmt.Sprintf("[%s]\n%s", a.Name(), a.Summary()))
+				break
+			}
+		}
+	}
+	if len(report.Accounts) == 0 {
+		return msg, false
+	}
+	if msg.Alert {
+		msg.Report = report
+		return msg, true
+	}
+	return Notification{
+		Title:   msg.Title,
+		Content: redactor.Redact(strings.Join(lines, "\n")),
+		Report:  report,
+	}, true
+}
+
+// sendDigest 将消息加入每日汇总, 上次推送汇总后已过了推送时间 (DigestAt) 时推送所有等待汇总的内容
+func (n *PolicyNotifier) sendDigest(msg Notification) error {
+	now := time.Now()
+	var accounts []*AccountReport
+	if msg.Report != nil {
+		accounts = msg.Report.Accounts
+	}
+	due, lastDigest := notifyState.AddDigest(n.key, DigestEntry{Time: now, Content: msg.Content, Accounts: accounts})
+	if !lastDigest.Before(digestCutoff(now, n.Policy.DigestAt)) {
+		return skippedError{"已加入每日汇总"}
+	}
+	var lines []string
+	for _, e := range due {
+		lines = append(lines, fmt.Sprintf("—— %s ——\n%s", e.Time.Format("2006-01-02 15:04"), e.Content))
+	}
+	err := n.Notifier.Send(Notification{
+		Title:   msg.Title + " (每日汇总)",
+		Content: strings.Join(lines, "\n"),
+		Report: &RunReport{
+			StartTime: due[0].Time,
+			EndTime:   due[len(due)-1].Time,
+			Accounts:  mergeAccountReports(due),
+		},
+	})
+	if sentOrQueued(err) {
+		notifyState.RemoveDigest(n.key, len(due))
+	}
+	return err
+}
+
+// mergeAccountReports 合并多次运行的账号结果, 签到取最后一次, 任务及错误累加, 云豆取首尾
+func mergeAccountReports(entries []DigestEntry) []*AccountReport {
+	var merged []*AccountReport
+	index := map[int]*AccountReport{}
+	for _, e := range entries {
+		for _, a := range e.Accounts {
+			m, ok := index[a.User]
+			if !ok {
+				m = &AccountReport{User: a.User, CookieValid: true}
+				index[a.User] = m
+				merged = append(merged, m)
+			}
+			if a.UserID != 0 {
+				m.UserID = a.UserID
+			}
+			if a.Nickname != "" {
+				m.Nickname = a.Nickname
+			}
+			m.CookieValid = m.CookieValid && a.CookieValid
+			if len(a.Signs) != 0 {
+				m.Signs = a.Signs
+			}
+			m.Missions = append(m.Missions, a.Missions...)
+			if a.BeanKnown {
+				if !m.BeanKnown {
+					m.BeanKnown = true
+					m.BeanBefore = a.BeanBefore
+				}
+				m.BeanAfter = a.BeanAfter
+			}
+			m.Errors = append(m.Errors, a.Errors...)
+			m.Warnings = append(m.Warnings, a.Warnings...)
+		}
+	}
+	return merged
+}
+
+// messageHash 消息内容的哈希, 用于去重
+func messageHash(msg Notification) string {
+	sum := sha256.Sum256([]byte(msg.Title + "\n" + msg.Content))
+	return hex.EncodeToString(sum[:])
+}
+
+// DigestEntry 等待汇总推送的一次运行结果
+type DigestEntry struct {
+	Time     time.Time        `json:"Time"`
+	Content  string           `json:"Content"`
+	Accounts []*AccountReport `json:"Accounts,omitempty"`
+}
+
+// NotifierState 推送后端的去重及汇总状态
+type NotifierState struct {
+	LastHash   string        `json:"LastHash,omitempty"`
+	LastSent   time.Time     `json:"LastSent,omitempty"`
+	LastDigest time.Time     `json:"LastDigest,omitempty"`
+	Digest     []DigestEntry `json:"Digest,omitempty"`
+}
+
+// NotifyState 各推送后端的状态, 保存在数据目录中以便单次运行模式下也能去重及汇总
+type NotifyState struct {
+	sync.Mutex
+	File      string
+	Notifiers map[string]*NotifierState
+}
+
+var notifyState NotifyState
+
+// Load 从文件读取推送状态
+func (s *NotifyState) Load(file string) error {
+	s.Lock()
+	defer s.Unlock()
+	s.File = file
+	s.Notifiers = map[string]*NotifierState{}
+	return loadJSON(file, &s.Notifiers)
+}
+
+// save 写入文件, 调用前需持有锁
+func (s *NotifyState) save() {
+	if s.File == "" {
+		return
+	}
+	if err := saveJSON(s.File, s.Notifiers); err != nil {
+		log.Errorf("写入推送状态失败: %v", err)
+	}
+}
+
+// get 返回推送后端的状态, 不存在时创建, 调用前需持有锁
+func (s *NotifyState) get(name string) *NotifierState {
+	if s.Notifiers == nil {
+		s.Notifiers = map[string]*NotifierState{}
+	}
+	state, ok := s.Notifiers[name]
+	if !ok {
+		state = &NotifierState{}
+		s.Notifiers[name] = state
+	}
+	return state
+}
+
+// Migrate 将旧版以推送名称保存的状态移动到 key 下, 已存在 key 的状态时不做修改
+func (s *NotifyState) Migrate(name, key string) {
+	s.Lock()
+	defer s.Unlock()
+	state, ok := s.Notifiers[name]
+	if !ok || name == key {
+		return
+	}
+	if _, exists := s.Notifiers[key]; !exists {
+		s.Notifiers[key] = state
+	}
+	delete(s.Notifiers, name)
+	s.save()
+}
+
+// Duplicate 相同内容是否已在 window 时间内推送过
+func (s *NotifyState) Duplicate(name, hash string, window time.Duration) bool {
+	s.Lock()
+	defer s.Unlock()
+	state := s.get(name)
+	return state.LastHash == hash && time.Since(state.LastSent) < window
+}
+
+// Sent 记录推送成功的内容
+func (s *NotifyState) Sent(name, hash string) {
+	s.Lock()
+	defer s.Unlock()
+	state := s.get(name)
+	state.LastHash = hash
+	state.LastSent = time.Now()
+	s.save()
+}
+
+// AddDigest 加入一条汇总内容, 返回所有等待汇总的内容及上次推送汇总的时间
+func (s *NotifyState) AddDigest(name string, entry DigestEntry) ([]DigestEntry, time.Time) {
+	s.Lock()
+	defer s.Unlock()
+	state := s.get(name)
+	state.Digest = append(state.Digest, entry)
+	s.save()
+	return append([]DigestEntry{}, state.Digest...), state.LastDigest
+}
+
+// RemoveDigest 移除已推送的前 n 条汇总内容
+func (s *NotifyState) RemoveDigest(name string, n int) {
+	s.Lock()
+	defer s.Unlock()
+	state := s.get(name)
+	if n > len(state.Digest) {
+		n = len(state.Digest)
+	}
+	state.Digest = state.Digest[n:]
+	state.LastSent = time.Now()
+	state.LastDigest = state.LastSent
+	s.save()
+}
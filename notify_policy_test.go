@@ -0,0 +1,73 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDigestCutoff(t *testing.T) {
+	day := func(d, h, m int) time.Time { return time.Date(2021, 8, d, h, m, 0, 0, time.Local) }
+	tests := []struct {
+		now  time.Time
+		at   string
+		want time.Time
+	}{
+		{day(24, 1, 0), "", day(24, 0, 0)},
+		{day(24, 1, 0), "13:00", day(23, 13, 0)},
+		{day(24, 13, 0), "13:00", day(24, 13, 0)},
+		{day(24, 13, 5), "13:00", day(24, 13, 0)},
+		{day(1, 0, 30), "23:30", day(0, 23, 30)},
+	}
+	for _, tt := range tests {
+		if got := digestCutoff(tt.now, tt.at); !got.Equal(tt.want) {
+			t.Errorf("digestCutoff(%s, %q) = %s, want %s", tt.now, tt.at, got, tt.want)
+		}
+	}
+}
+
+// countNotifier 记录发送次数的推送后端
+type countNotifier struct {
+	sent []Notification
+}
+
+func (c *countNotifier) Name() string { return "count" }
+
+func (c *countNotifier) Send(msg Notification) error {
+	c.sent = append(c.sent, msg)
+	return nil
+}
+
+func TestSendDigest(t *testing.T) {
+	notifyState = NotifyState{}
+	defer func() { notifyState = NotifyState{} }()
+	now := time.Now()
+	upcoming := now.Add(time.Hour).Format("15:04")
+	tests := []struct {
+		name       string
+		digestAt   string
+		lastDigest time.Time
+		wantSent   int
+	}{
+		{"从未推送过时立即推送", "", time.Time{}, 1},
+		{"今天已推送过时加入汇总", "", digestCutoff(now, ""), 0},
+		{"昨天推送过且已过推送时间时推送, 包括本次", "", now.AddDate(0, 0, -1), 1},
+		{"未到推送时间时加入汇总", upcoming, now.Add(-2 * time.Minute), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := tt.name
+			notifyState.Lock()
+			notifyState.get(key).LastDigest = tt.lastDigest
+			notifyState.Unlock()
+			c := &countNotifier{}
+			n := &PolicyNotifier{Notifier: c, Policy: NotifyPolicy{Digest: true, DigestAt: tt.digestAt}, key: key}
+			_ = n.sendDigest(Notification{Title: "运行结果", Content: "本次"})
+			if len(c.sent) != tt.wantSent {
+				t.Fatalf("发送了 %d 次, want %d", len(c.sent), tt.wantSent)
+			}
+			if tt.wantSent != 0 && c.sent[0].Content == "" {
+				t.Errorf("汇总内容为空")
+			}
+		})
+	}
+}
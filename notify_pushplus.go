@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// PushPlusNotifier PushPlus 推送
+type PushPlusNotifier struct {
+	name     string
+	Token    string `json:"Token"`
+	Topic    string `json:"Topic"`
+	Template string `json:"Template"`
+	URL      string `json:"URL"`
+}
+
+func init() {
+	registerNotifier("pushplus", newPushPlusNotifier)
+}
+
+func newPushPlusNotifier(name string, options json.RawMessage) (Notifier, error) {
+	n := &PushPlusNotifier{name: name}
+	if err := json.Unmarshal(options, n); err != nil {
+		return nil, err
+	}
+	if n.Token == "" {
+		return nil, fmt.Errorf("Token 不能为空")
+	}
+	if n.URL == "" {
+		n.URL = "http://www.pushplus.plus/send"
+	}
+	return n, nil
+}
+
+// Name 实现 Notifier
+func (n *PushPlusNotifier) Name() string {
+	return n.name
+}
+
+// Send 实现 Notifier
+func (n *PushPlusNotifier) Send(msg Notification) error {
+	data := url.Values{}
+	data.Set("token", n.Token)
+	data.Set("title", msg.Title)
+	data.Set("content", msg.Content)
+	if n.Topic != "" {
+		data.Set("topic", n.Topic)
+	}
+	if n.Template != "" {
+		data.Set("template", n.Template)
+	}
+	var result struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	err := postForm(n.URL, data, &result)
+	if err != nil {
+		return err
+	}
+	if result.Code != 200 {
+		return fmt.Errorf("代码: %d, 原因: \"%s\"", result.Code, result.Msg)
+	}
+	return nil
+}
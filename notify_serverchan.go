@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ServerChanNotifier Server酱推送
+type ServerChanNotifier struct {
+	name    string
+	SendKey string `json:"SendKey"`
+	URL     string `json:"URL"`
+}
+
+func init() {
+	registerNotifier("serverchan", newServerChanNotifier)
+}
+
+func newServerChanNotifier(name string, options json.RawMessage) (Notifier, error) {
+	n := &ServerChanNotifier{name: name}
+	if err := json.Unmarshal(options, n); err != nil {
+		return nil, err
+	}
+	if n.SendKey == "" {
+		return nil, fmt.Errorf("SendKey 不能为空")
+	}
+	if n.URL == "" {
+		if strings.HasPrefix(n.SendKey, "SCT") {
+			n.URL = fmt.Sprintf("https://sctapi.ftqq.com/%s.send", n.SendKey)
+		} else {
+			n.URL = fmt.Sprintf("https://sc.ftqq.com/%s.send", n.SendKey)
+		}
+	}
+	return n, nil
+}
+
+// Name 实现 Notifier
+func (n *ServerChanNotifier) Name() string {
+	return n.name
+}
+
+// Send 实现 Notifier
+func (n *ServerChanNotifier) Send(msg Notification) error {
+	message := struct {
+		Title string `json:"title"`
+		Desp  string `json:"desp"`
+	}{
+		Title: msg.Title,
+		Desp:  strings.ReplaceAll(msg.Content, "\n", "\n\n"),
+	}
+	var result struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Errno   *int   `json:"errno"`
+		Errmsg  string `json:"errmsg"`
+	}
+	err := postJSON(n.URL, message, &result)
+	if err != nil {
+		return err
+	}
+	if result.Errno != nil && *result.Errno != 0 {
+		return fmt.Errorf("代码: %d, 原因: \"%s\"", *result.Errno, result.Errmsg)
+	}
+	if result.Code != 0 {
+		return fmt.Errorf("代码: %d, 原因: \"%s\"", result.Code, result.Message)
+	}
+	return nil
+}
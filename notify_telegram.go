@@ -0,0 +1,221 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+	"unicode/utf8"
+)
+
+// telegramMaxLength Telegram 单条消息的最大长度
+const telegramMaxLength = 4096
+
+// TelegramNotifier Telegram Bot 推送
+type TelegramNotifier struct {
+	name      string
+	BotToken  string           `json:"BotToken"`
+	ChatIDs   []TelegramChatID `json:"ChatIDs"`
+	ThreadID  int              `json:"ThreadID"`
+	ParseMode string           `json:"ParseMode"`
+	APIURL    string           `json:"APIURL"`
+}
+
+// TelegramChatID 兼容数字及字符串 (如 @channel) 格式的 chat id
+type TelegramChatID string
+
+// UnmarshalJSON 实现 json.Unmarshaler
+func (id *TelegramChatID) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*id = TelegramChatID(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(b, &n); err != nil {
+		return err
+	}
+	*id = TelegramChatID(n.String())
+	return nil
+}
+
+func init() {
+	registerNotifier("telegram", newTelegramNotifier)
+}
+
+func newTelegramNotifier(name string, options json.RawMessage) (Notifier, error) {
+	n := &TelegramNotifier{name: name}
+	if err := json.Unmarshal(options, n); err != nil {
+		return nil, err
+	}
+	if n.BotToken == "" {
+		return nil, fmt.Errorf("BotToken 不能为空")
+	}
+	if len(n.ChatIDs) == 0 {
+		return nil, fmt.Errorf("ChatIDs 不能为空")
+	}
+	switch strings.ToLower(n.ParseMode) {
+	case "", "html":
+		n.ParseMode = "HTML"
+	case "markdownv2":
+		n.ParseMode = "MarkdownV2"
+	default:
+		return nil, fmt.Errorf("不支持的 ParseMode \"%s\", 可选 HTML 或 MarkdownV2", n.ParseMode)
+	}
+	if n.APIURL == "" {
+		n.APIURL = "https://api.telegram.org"
+	}
+	n.APIURL = strings.TrimRight(n.APIURL, "/")
+	return n, nil
+}
+
+// Name 实现 Notifier
+func (n *TelegramNotifier) Name() string {
+	return n.name
+}
+
+// Send 实现 Notifier, 部分 chat 或拆分后的部分消息发送失败时返回 partialError,
+// 重试时只发送给失败的 chat, 并跳过该 chat 已发送成功的消息
+func (n *TelegramNotifier) Send(msg Notification) error {
+	var errs []string
+	var failed []string
+	delivered := map[string]int{}
+	var progressed bool
+	chatIDs := n.recipients(msg)
+	texts := n.format(msg)
+	for _, chatID := range chatIDs {
+		sent := msg.Delivered[string(chatID)]
+		for ; sent < len(texts); sent++ {
+			if err := n.sendMessage(chatID, texts[sent]); err != nil {
+				errs = append(errs, fmt.Sprintf("chat %s: %v", chatID, err))
+				break
+			}
+			progressed = true
+		}
+		if sent < len(texts) {
+			failed = append(failed, string(chatID))
+			if sent != 0 {
+				delivered[string(chatID)] = sent
+			}
+		} else {
+			progressed = true
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	err := fmt.Errorf("%s", strings.Join(errs, "; "))
+	if !progressed {
+		return err
+	}
+	remaining := msg
+	remaining.Recipients = failed
+	remaining.Delivered = delivered
+	return partialError{err: err, remaining: remaining}
+}
+
+// sendMessage 发送一条消息
+func (n *TelegramNotifier) sendMessage(chatID TelegramChatID, text string) error {
+	body := map[string]interface{}{
+		"chat_id":                  string(chatID),
+		"text":                     text,
+		"parse_mode":               n.ParseMode,
+		"disable_web_page_preview": true,
+	}
+	if n.ThreadID != 0 {
+		body["message_thread_id"] = n.ThreadID
+	}
+	var result struct {
+		OK          bool   `json:"ok"`
+		ErrorCode   int    `json:"error_code"`
+		Description string `json:"description"`
+	}
+	err := postJSON(fmt.Sprintf("%s/bot%s/sendMessage", n.APIURL, n.BotToken), body, &result)
+	if err == nil && !result.OK {
+		err = fmt.Errorf("代码: %d, 原因: \"%s\"", result.ErrorCode, result.Description)
+	}
+	return err
+}
+
+// recipients 返回需要发送的 chat, msg.Recipients 不为空时只发送给其中的 chat
+func (n *TelegramNotifier) recipients(msg Notification) []TelegramChatID {
+	if len(msg.Recipients) == 0 {
+		return n.ChatIDs
+	}
+	var chatIDs []TelegramChatID
+	for _, chatID := range n.ChatIDs {
+		for _, r := range msg.Recipients {
+			if string(chatID) == r {
+				chatIDs = append(chatIDs, chatID)
+				break
+			}
+		}
+	}
+	return chatIDs
+}
+
+// escape 按 ParseMode 转义文本
+func (n *TelegramNotifier) escape(s string) string {
+	if n.ParseMode == "MarkdownV2" {
+		var b strings.Builder
+		for _, r := range s {
+			if strings.ContainsRune("_*[]()~`>#+-=|{}.!\\", r) {
+				b.WriteRune('\\')
+			}
+			b.WriteRune(r)
+		}
+		return b.String()
+	}
+	return html.EscapeString(s)
+}
+
+// bold 按 ParseMode 加粗标题
+func (n *TelegramNotifier) bold(s string) string {
+	if n.ParseMode == "MarkdownV2" {
+		return "*" + n.escape(s) + "*"
+	}
+	return "<b>" + n.escape(s) + "</b>"
+}
+
+// format 生成消息文本, 超过长度限制时按行拆分为多条
+func (n *TelegramNotifier) format(msg Notification) []string {
+	var lines []string
+	if msg.Title != "" {
+		lines = append(lines, n.bold(msg.Title))
+	}
+	for _, line := range strings.Split(msg.Content, "\n") {
+		for _, part := range splitRunes(line, telegramMaxLength/4) {
+			lines = append(lines, n.escape(part))
+		}
+	}
+	var messages []string
+	var current strings.Builder
+	for _, line := range lines {
+		if current.Len() != 0 && utf8.RuneCountInString(current.String())+1+utf8.RuneCountInString(line) > telegramMaxLength {
+			messages = append(messages, current.String())
+			current.Reset()
+		}
+		if current.Len() != 0 {
+			current.WriteString("\n")
+		}
+		current.WriteString(line)
+	}
+	if current.Len() != 0 {
+		messages = append(messages, current.String())
+	}
+	return messages
+}
+
+// splitRunes 将字符串拆分为最多 size 个字符的片段
+func splitRunes(s string, size int) []string {
+	runes := []rune(s)
+	if len(runes) <= size {
+		return []string{s}
+	}
+	var parts []string
+	for len(runes) > size {
+		parts = append(parts, string(runes[:size]))
+		runes = runes[size:]
+	}
+	return append(parts, string(runes))
+}
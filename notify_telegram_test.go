@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTelegramEscape(t *testing.T) {
+	tests := []struct {
+		parseMode string
+		in        string
+		want      string
+	}{
+		{"HTML", "<b>a & b</b>", "&lt;b&gt;a &amp; b&lt;/b&gt;"},
+		{"", "1 < 2", "1 &lt; 2"},
+		{"MarkdownV2", "云豆 +5 (共 10.5)", "云豆 \\+5 \\(共 10\\.5\\)"},
+		{"MarkdownV2", "a_b*c[d]~`>#=|{}!\\", "a\\_b\\*c\\[d\\]\\~\\`\\>\\#\\=\\|\\{\\}\\!\\\\"},
+	}
+	for _, tt := range tests {
+		n := &TelegramNotifier{ParseMode: tt.parseMode}
+		if got := n.escape(tt.in); got != tt.want {
+			t.Errorf("escape(%q) [%s] = %q, want %q", tt.in, tt.parseMode, got, tt.want)
+		}
+	}
+}
+
+func TestSplitRunes(t *testing.T) {
+	tests := []struct {
+		in   string
+		size int
+		want []string
+	}{
+		{"", 3, []string{""}},
+		{"abc", 3, []string{"abc"}},
+		{"abcdefg", 3, []string{"abc", "def", "g"}},
+		{"网易云音乐签到", 2, []string{"网易", "云音", "乐签", "到"}},
+	}
+	for _, tt := range tests {
+		got := splitRunes(tt.in, tt.size)
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") || len(got) != len(tt.want) {
+			t.Errorf("splitRunes(%q, %d) = %q, want %q", tt.in, tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestTelegramFormat(t *testing.T) {
+	n := &TelegramNotifier{ParseMode: "HTML"}
+	got := n.format(Notification{Title: "标题", Content: "第一行\n<第二行>"})
+	if len(got) != 1 || got[0] != "<b>标题</b>\n第一行\n&lt;第二行&gt;" {
+		t.Errorf("format() = %q", got)
+	}
+
+	long := strings.Repeat("云", telegramMaxLength)
+	content := strings.Repeat("签到成功\n", 2000) + long
+	messages := n.format(Notification{Title: "标题", Content: content})
+	if len(messages) < 2 {
+		t.Fatalf("超长内容应拆分为多条, 实际 %d 条", len(messages))
+	}
+	var total int
+	for i, m := range messages {
+		if l := utf8.RuneCountInString(m); l > telegramMaxLength {
+			t.Errorf("第 %d 条长度 %d 超过限制", i, l)
+		}
+		total += strings.Count(m, "云")
+	}
+	if total != telegramMaxLength {
+		t.Errorf("拆分后内容不完整: %d 个字符, want %d", total, telegramMaxLength)
+	}
+}
+
+func TestTelegramSendRetry(t *testing.T) {
+	type sent struct{ chat, text string }
+	var received []sent
+	failAt := map[string]int{} // chat 发送到第几条 (从 0 开始) 时失败, 只失败一次
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			ChatID string `json:"chat_id"`
+			Text   string `json:"text"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		var count int
+		for _, s := range received {
+			if s.chat == body.ChatID {
+				count++
+			}
+		}
+		if i, ok := failAt[body.ChatID]; ok && i == count {
+			delete(failAt, body.ChatID)
+			_, _ = w.Write([]byte(`{"ok":false,"error_code":429,"description":"Too Many Requests"}`))
+			return
+		}
+		received = append(received, sent{body.ChatID, body.Text})
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	n := &TelegramNotifier{BotToken: "token", ChatIDs: []TelegramChatID{"1", "2"}, ParseMode: "HTML", APIURL: srv.URL}
+	var lines []string
+	for _, r := range "一二三四五六七八九" {
+		lines = append(lines, strings.Repeat(string(r), 1000))
+	}
+	msg := Notification{Content: strings.Join(lines, "\n")}
+	parts := len(n.format(msg))
+	if parts < 3 {
+		t.Fatalf("消息应拆分为至少 3 条, 实际 %d 条", parts)
+	}
+	tests := []struct {
+		name          string
+		failAt        map[string]int
+		wantPartial   bool
+		wantRecipient []string
+		wantDelivered map[string]int
+	}{
+		{"全部成功", map[string]int{}, false, nil, nil},
+		{"第一个 chat 第一条失败", map[string]int{"1": 0}, true, []string{"1"}, map[string]int{}},
+		{"第二个 chat 中途失败", map[string]int{"2": 1}, true, []string{"2"}, map[string]int{"2": 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received = nil
+			failAt = tt.failAt
+			err := n.Send(msg)
+			var partial partialError
+			if errors.As(err, &partial) != tt.wantPartial {
+				t.Fatalf("Send() error = %v, wantPartial %v", err, tt.wantPartial)
+			}
+			if !tt.wantPartial {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(received) != 2*parts {
+					t.Errorf("发送了 %d 条, want %d", len(received), 2*parts)
+				}
+				return
+			}
+			remaining := partial.remaining
+			if strings.Join(remaining.Recipients, ",") != strings.Join(tt.wantRecipient, ",") {
+				t.Errorf("Recipients = %v, want %v", remaining.Recipients, tt.wantRecipient)
+			}
+			for chat, want := range tt.wantDelivered {
+				if remaining.Delivered[chat] != want {
+					t.Errorf("Delivered[%s] = %d, want %d", chat, remaining.Delivered[chat], want)
+				}
+			}
+			if err := n.Send(remaining); err != nil {
+				t.Fatalf("重试失败: %v", err)
+			}
+			counts := map[string]int{}
+			texts := map[string]map[string]bool{}
+			for _, s := range received {
+				counts[s.chat]++
+				if texts[s.chat] == nil {
+					texts[s.chat] = map[string]bool{}
+				}
+				if texts[s.chat][s.text] {
+					t.Errorf("chat %s 重复收到同一条消息", s.chat)
+				}
+				texts[s.chat][s.text] = true
+			}
+			for _, chat := range []string{"1", "2"} {
+				if counts[chat] != parts {
+					t.Errorf("chat %s 共收到 %d 条, want %d", chat, counts[chat], parts)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAccountMessages(t *testing.T) {
+	report := &RunReport{Accounts: []*AccountReport{
+		{User: 0, Nickname: "小明", CookieValid: true},
+		{User: 1, CookieValid: true},
+	}}
+	tests := []struct {
+		name   string
+		msg    Notification
+		titles []string
+	}{
+		{"没有运行报告", Notification{Title: "标题"}, []string{"标题"}},
+		{"告警不拆分", Notification{Title: "Cookie 已失效", Report: report, Alert: true}, []string{"Cookie 已失效"}},
+		{"按账号拆分", Notification{Title: "运行结果", Report: report}, []string{"运行结果 - User[0] 小明", "运行结果 - User[1]"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages := accountMessages(tt.msg)
+			if len(messages) != len(tt.titles) {
+				t.Fatalf("拆分为 %d 条, want %d", len(messages), len(tt.titles))
+			}
+			for i, m := range messages {
+				if m.Title != tt.titles[i] {
+					t.Errorf("第 %d 条标题 = %q, want %q", i, m.Title, tt.titles[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSendAccountMessages(t *testing.T) {
+	report := &RunReport{Accounts: []*AccountReport{{User: 0}, {User: 1}, {User: 2}}}
+	sendErr := errors.New("发送失败")
+	tests := []struct {
+		name          string
+		failAt        int
+		wantErr       bool
+		wantPartial   bool
+		wantRemaining []int
+	}{
+		{"全部成功", -1, false, false, nil},
+		{"第一条失败时整体重试", 0, true, false, nil},
+		{"部分成功时只重试剩余账号", 1, true, true, []int{1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent int
+			err := sendAccountMessages(Notification{Title: "运行结果", Report: report}, func(m Notification) error {
+				if sent == tt.failAt {
+					return sendErr
+				}
+				sent++
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			partial, ok := err.(partialError)
+			if ok != tt.wantPartial {
+				t.Fatalf("partialError = %v, want %v", ok, tt.wantPartial)
+			}
+			if !ok {
+				return
+			}
+			var users []int
+			for _, a := range partial.remaining.Report.Accounts {
+				users = append(users, a.User)
+			}
+			if len(users) != len(tt.wantRemaining) || users[0] != tt.wantRemaining[0] {
+				t.Errorf("剩余账号 = %v, want %v", users, tt.wantRemaining)
+			}
+		})
+	}
+}
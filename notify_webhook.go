@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// defaultWebhookBody 默认的 Webhook 请求内容
+const defaultWebhookBody = `{"title": {{json .Title}}, "content": {{json .Content}}}`
+
+// WebhookNotifier 通用 Webhook 推送, 请求内容由 text/template 模板生成
+type WebhookNotifier struct {
+	name        string
+	URL         string            `json:"URL"`
+	Method      string            `json:"Method"`
+	Headers     map[string]string `json:"Headers"`
+	ContentType string            `json:"ContentType"`
+	Body        string            `json:"Body"`
+	body        *template.Template
+}
+
+// WebhookData Webhook 模板可使用的数据
+type WebhookData struct {
+	Title    string
+	Content  string
+	Time     time.Time
+	Failed   bool
+	Accounts []*AccountReport
+}
+
+func init() {
+	registerNotifier("webhook", newWebhookNotifier)
+}
+
+// webhookFuncs Webhook 模板函数
+var webhookFuncs = template.FuncMap{
+	// json 输出 JSON 格式的值, 字符串会带上引号
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	// jsonEscape 转义字符串以便放入 JSON 字符串中, 不带引号
+	"jsonEscape": func(s string) (string, error) {
+		b, err := json.Marshal(s)
+		if err != nil {
+			return "", err
+		}
+		return string(b[1 : len(b)-1]), nil
+	},
+	"join": strings.Join,
+	"replace": func(s, old, new string) string {
+		return strings.ReplaceAll(s, old, new)
+	},
+	"formatTime": func(t time.Time, layout string) string {
+		return t.Local().Format(layout)
+	},
+}
+
+func newWebhookNotifier(name string, options json.RawMessage) (Notifier, error) {
+	n := &WebhookNotifier{name: name}
+	if err := json.Unmarshal(options, n); err != nil {
+		return nil, err
+	}
+	if n.URL == "" {
+		return nil, fmt.Errorf("URL 不能为空")
+	}
+	if n.Method == "" {
+		n.Method = http.MethodPost
+	}
+	n.Method = strings.ToUpper(n.Method)
+	if n.ContentType == "" {
+		n.ContentType = "application/json"
+	}
+	if n.Body == "" {
+		n.Body = defaultWebhookBody
+	}
+	var err error
+	n.body, err = template.New(name).Funcs(webhookFuncs).Parse(n.Body)
+	if err != nil {
+		return nil, fmt.Errorf("解析 Body 模板失败: %v", err)
+	}
+	return n, nil
+}
+
+// Name 实现 Notifier
+func (n *WebhookNotifier) Name() string {
+	return n.name
+}
+
+// Send 实现 Notifier
+func (n *WebhookNotifier) Send(msg Notification) error {
+	data := WebhookData{
+		Title:   msg.Title,
+		Content: msg.Content,
+		Time:    time.Now(),
+	}
+	if msg.Report != nil {
+		data.Failed = msg.Report.Failed()
+		data.Accounts = msg.Report.Accounts
+	}
+	var body bytes.Buffer
+	err := n.body.Execute(&body, data)
+	if err != nil {
+		return fmt.Errorf("生成请求内容失败: %v", err)
+	}
+	return doNotifyRequest(n.Method, n.URL, n.ContentType, strings.NewReader(redactor.Redact(body.String())), n.Headers, nil)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"unicode/utf8"
+)
+
+// wecomMaxLength 企业微信 markdown 消息的最大字节数
+const wecomMaxLength = 4096
+
+// WeComNotifier 企业微信群机器人推送
+type WeComNotifier struct {
+	name string
+	Key  string `json:"Key"`
+	URL  string `json:"URL"`
+}
+
+func init() {
+	registerNotifier("wecom", newWeComNotifier)
+}
+
+func newWeComNotifier(name string, options json.RawMessage) (Notifier, error) {
+	n := &WeComNotifier{name: name}
+	if err := json.Unmarshal(options, n); err != nil {
+		return nil, err
+	}
+	if n.Key == "" && n.URL == "" {
+		return nil, fmt.Errorf("Key 不能为空")
+	}
+	if n.URL == "" {
+		n.URL = "https://qyapi.weixin.qq.com/cgi-bin/webhook/send?key=" + url.QueryEscape(n.Key)
+	}
+	return n, nil
+}
+
+// Name 实现 Notifier
+func (n *WeComNotifier) Name() string {
+	return n.name
+}
+
+// Send 实现 Notifier
+func (n *WeComNotifier) Send(msg Notification) error {
+	message := map[string]interface{}{
+		"msgtype": "markdown",
+		"markdown": map[string]string{
+			"content": truncateBytes("**"+msg.Title+"**\n"+msg.Content, wecomMaxLength),
+		},
+	}
+	var result struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	err := postJSON(n.URL, message, &result)
+	if err != nil {
+		return err
+	}
+	if result.ErrCode != 0 {
+		return fmt.Errorf("代码: %d, 原因: \"%s\"", result.ErrCode, result.ErrMsg)
+	}
+	return nil
+}
+
+// truncateBytes 将字符串截断为最多 size 字节, 不截断 UTF-8 字符
+func truncateBytes(s string, size int) string {
+	if len(s) <= size {
+		return s
+	}
+	s = s[:size]
+	for len(s) > 0 && !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// redactMask 替换敏感信息的字符串
+const redactMask = "******"
+
+// minSecretLength 短于此长度的配置值不作为敏感信息处理, 避免误伤正常内容
+const minSecretLength = 6
+
+// Redactor 隐藏日志、报告及推送内容中的敏感信息
+type Redactor struct {
+	sync.RWMutex
+	secrets []string
+}
+
+var redactor Redactor
+
+// secretPatterns 常见的 Cookie / Token 格式
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b(MUSIC_U|MUSIC_A|MUSIC_A_T|MUSIC_R_T|MUSIC_R_U|__csrf|__remember_me|NMTID|token|sendkey|access_token|secret|password)(=|:\s*|":\s*")([^;&\s"',]+)`),
+	regexp.MustCompile(`(?i)(Authorization:?\s*(?:Bearer|Basic)\s+)(\S+)`),
+	regexp.MustCompile(`(sc\.ftqq\.com/|sctapi\.ftqq\.com/)([^./\s]+)`),
+}
+
+// AddSecrets 添加需要隐藏的敏感信息
+func (r *Redactor) AddSecrets(secrets ...string) {
+	r.Lock()
+	defer r.Unlock()
+	for _, secret := range secrets {
+		if len(secret) < minSecretLength {
+			continue
+		}
+		r.secrets = append(r.secrets, secret)
+		if escaped := url.QueryEscape(secret); escaped != secret {
+			r.secrets = append(r.secrets, escaped) // 请求中的 Cookie 会经过 URL 编码
+		}
+	}
+	// 先替换较长的值, 避免较短的值是其子串时替换不完整
+	sort.Slice(r.secrets, func(i, j int) bool {
+		return len(r.secrets[i]) > len(r.secrets[j])
+	})
+}
+
+// Redact 隐藏字符串中的敏感信息
+func (r *Redactor) Redact(s string) string {
+	r.RLock()
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, redactMask)
+	}
+	r.RUnlock()
+	for i, pattern := range secretPatterns {
+		if i == 0 {
+			s = pattern.ReplaceAllString(s, "${1}${2}"+redactMask)
+		} else {
+			s = pattern.ReplaceAllString(s, "${1}"+redactMask)
+		}
+	}
+	return s
+}
+
+// registerConfigSecrets 将配置文件中的 Cookie 及各种 Token 添加为敏感信息
+func registerConfigSecrets() {
+	for _, user := range config.Users {
+		for _, cookie := range user.Cookies {
+			redactor.AddSecrets(cookie.Value)
+		}
+		if u, err := url.Parse(user.Proxy); err == nil && u.User != nil {
+			password, _ := u.User.Password()
+			redactor.AddSecrets(password)
+		}
+	}
+	redactor.AddSecrets(config.PushPlusToken, config.ServerSendKey, config.Server.Token)
+	registerNotifierSecrets()
+}
+
+// RedactFormatter 在输出前隐藏敏感信息的 log 格式
+type RedactFormatter struct {
+	Formatter log.Formatter
+}
+
+// Format 实现 log.Formatter
+func (f *RedactFormatter) Format(entry *log.Entry) ([]byte, error) {
+	b, err := f.Formatter.Format(entry)
+	if err != nil {
+		return b, err
+	}
+	return []byte(redactor.Redact(string(b))), nil
+}
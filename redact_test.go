@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestRedact(t *testing.T) {
+	r := &Redactor{}
+	r.AddSecrets("short", "configured-secret-value", "a b+c/d=e&f")
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"Cookie 请求头", "Cookie: MUSIC_U=abcdef123456; os=pc", "Cookie: MUSIC_U=******; os=pc"},
+		{"JSON 字段", `{"token": "t0k3n", "name": "小明"}`, `{"token": "******", "name": "小明"}`},
+		{"键值对不区分大小写", "music_a: xyz, __csrf=aaa&b=1", "music_a: ******, __csrf=******&b=1"},
+		{"Authorization 请求头", "Authorization: Bearer abc.def.ghi", "Authorization: Bearer ******"},
+		{"Server 酱地址", "https://sctapi.ftqq.com/SCT123abc.send", "https://sctapi.ftqq.com/******.send"},
+		{"配置中的敏感信息", "value is configured-secret-value!", "value is ******!"},
+		{"URL 编码后的敏感信息", "x=a+b%2Bc%2Fd%3De%26f", "x=******"},
+		{"过短的值不处理", "a short text", "a short text"},
+		{"普通内容不变", "今日签到成功, 获得 5 云豆", "今日签到成功, 获得 5 云豆"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.Redact(tt.in); got != tt.want {
+				t.Errorf("Redact(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RunReport 一次运行的结果, 用于生成推送内容
+type RunReport struct {
+	sync.Mutex
+	StartTime time.Time
+	EndTime   time.Time
+	Accounts  []*AccountReport
+}
+
+// AccountReport 单个账号的运行结果
+type AccountReport struct {
+	User        int
+	UserID      int
+	Nickname    string
+	CookieValid bool
+	Signs       []SignReport
+	Missions    []MissionReport
+	BeanKnown   bool
+	BeanBefore  int
+	BeanAfter   int
+	Errors      []string
+	Warnings    []string // 不影响任务结果的警告, 如登录状态即将失效
+}
+
+// SignReport 签到结果
+type SignReport struct {
+	Platform string
+	Success  bool
+	Message  string
+}
+
+// MissionReport 已领取云豆的音乐人任务
+type MissionReport struct {
+	Description string
+	Reward      string
+}
+
+// BeanDelta 本次运行云豆变化
+func (a *AccountReport) BeanDelta() int {
+	return a.BeanAfter - a.BeanBefore
+}
+
+// Failed 账号是否有失败的任务
+func (a *AccountReport) Failed() bool {
+	return !a.CookieValid || len(a.Errors) != 0
+}
+
+var runReport = &RunReport{}
+
+// newRunReport 开始记录新一次运行的结果, 并清空上次运行的推送内容
+func newRunReport() {
+	runReport = &RunReport{StartTime: time.Now()}
+	pushMsg = ""
+}
+
+// Account 返回某个用户的运行结果, 不存在时创建
+func (r *RunReport) Account(user int) *AccountReport {
+	r.Lock()
+	defer r.Unlock()
+	for _, a := range r.Accounts {
+		if a.User == user {
+			return a
+		}
+	}
+	a := &AccountReport{User: user}
+	r.Accounts = append(r.Accounts, a)
+	return a
+}
+
+// Failed 是否有账号失败
+func (r *RunReport) Failed() bool {
+	for _, a := range r.Accounts {
+		if a.Failed() {
+			return true
+		}
+	}
+	return false
+}
+
+// reportAccount 返回当前用户 (processingUser) 的运行结果
+func reportAccount() *AccountReport {
+	return runReport.Account(processingUser)
+}
+
+// reportCloudBean 记录当前用户的云豆数, 第一次记录的值作为运行前的云豆数
+func reportCloudBean(cloudBean int) {
+	a := reportAccount()
+	if !a.BeanKnown {
+		a.BeanKnown = true
+		a.BeanBefore = cloudBean
+	}
+	a.BeanAfter = cloudBean
+}
+
+// 运行结果分类, 用于推送的优先级
+const (
+	OutcomeSuccess       = "success"
+	OutcomeWarning       = "warning"
+	OutcomeFailure       = "failure"
+	OutcomeCookieExpired = "cookie_expired"
+)
+
+// Outcome 账号的运行结果分类
+func (a *AccountReport) Outcome() string {
+	if !a.CookieValid {
+		return OutcomeCookieExpired
+	}
+	if len(a.Errors) != 0 {
+		return OutcomeFailure
+	}
+	if len(a.Warnings) != 0 {
+		return OutcomeWarning
+	}
+	return OutcomeSuccess
+}
+
+// Outcome 本次运行的结果分类, 取所有账号中最严重的结果
+func (r *RunReport) Outcome() string {
+	outcome := OutcomeSuccess
+	if r == nil {
+		return outcome
+	}
+	for _, a := range r.Accounts {
+		if o := a.Outcome(); severityOrder[o] > severityOrder[outcome] {
+			outcome = o
+		}
+	}
+	return outcome
+}
+
+// Name 账号显示名称
+func (a *AccountReport) Name() string {
+	if a.Nickname != "" {
+		return fmt.Sprintf("User[%d] %s", a.User, a.Nickname)
+	}
+	return fmt.Sprintf("User[%d]", a.User)
+}
+
+// Summary 账号运行结果的文本摘要
+func (a *AccountReport) Summary() string {
+	var lines []string
+	if !a.CookieValid {
+		lines = append(lines, "Cookie 已失效")
+	}
+	for _, s := range a.Signs {
+		if s.Success {
+			lines = append(lines, fmt.Sprintf("%s 签到成功", s.Platform))
+		} else {
+			lines = append(lines, fmt.Sprintf("%s 签到失败: %s", s.Platform, s.Message))
+		}
+	}
+	for _, m := range a.Missions {
+		lines = append(lines, fmt.Sprintf("[%s] 领取云豆 %s", m.Description, m.Reward))
+	}
+	if a.BeanKnown {
+		lines = append(lines, fmt.Sprintf("云豆: %d (%+d)", a.BeanAfter, a.BeanDelta()))
+	}
+	for _, e := range a.Errors {
+		lines = append(lines, "错误: "+e)
+	}
+	for _, w := range a.Warnings {
+		lines = append(lines, "警告: "+w)
+	}
+	return strings.Join(lines, "\n")
+}
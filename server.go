@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// startServer 启动内置 HTTP 服务 (仅在 Cron 模式下启用)
+func startServer() {
+	if !config.Cron.Enabled || !config.Server.Enabled {
+		return
+	}
+	listen := config.Server.Listen
+	if listen == "" {
+		listen = "127.0.0.1:9163"
+	}
+	http.DefaultTransport = &instrumentedTransport{next: http.DefaultTransport}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics)
+	mux.HandleFunc("/healthz", handleHealthz)
+	mux.HandleFunc("/readyz", handleReadyz)
+	if config.Server.Token != "" {
+		mux.Handle("/status", requireToken(http.HandlerFunc(handleStatus)))
+	} else {
+		mux.HandleFunc("/status", handlePublicStatus)
+	}
+	registerDashboard(mux)
+
+	go func() {
+		log.Printf("[Server] HTTP 服务已启动, 监听地址: %s", listen)
+		err := http.ListenAndServe(listen, mux)
+		if err != nil {
+			log.Errorf("[Server] HTTP 服务启动失败: %v", err)
+		}
+	}()
+}
+
+// handleHealthz 进程存活即返回 200
+func handleHealthz(w http.ResponseWriter, _ *http.Request) {
+	_, _ = w.Write([]byte("ok"))
+}
+
+// handleReadyz Cron 任务创建成功后返回 200
+func handleReadyz(w http.ResponseWriter, _ *http.Request) {
+	if !daemonStatus.IsReady() {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+		return
+	}
+	_, _ = w.Write([]byte("ok"))
+}
+
+// handleStatus 返回各账号的运行状态
+func handleStatus(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(daemonStatus.Snapshot())
+}
+
+// handlePublicStatus 未设置 Server.Token 时返回不含账号信息的运行状态
+func handlePublicStatus(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(daemonStatus.Snapshot().Public())
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/XiaoMengXinX/Fuck163MusicTasks/v2/configfile"
+	"github.com/XiaoMengXinX/Music163Api-Go/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// TokenRefreshAPI 刷新登录状态 API
+const TokenRefreshAPI = "/api/login/token/refresh"
+
+const (
+	// sessionDefaultInterval 默认刷新间隔
+	sessionDefaultInterval = 7 * 24 * time.Hour
+	// sessionDefaultWarnBefore 默认在 MUSIC_U 过期前多久发出警告
+	sessionDefaultWarnBefore = 7 * 24 * time.Hour
+	// sessionRetryInterval MUSIC_U 即将过期时两次刷新的最短间隔
+	sessionRetryInterval = 24 * time.Hour
+)
+
+// sessionInterval 登录状态的刷新间隔
+func sessionInterval() time.Duration {
+	if config.SessionRefresh.Interval > 0 {
+		return time.Duration(config.SessionRefresh.Interval) * time.Second
+	}
+	return sessionDefaultInterval
+}
+
+// sessionWarnBefore MUSIC_U 剩余有效期少于此时长时发出警告
+func sessionWarnBefore() time.Duration {
+	if config.SessionRefresh.WarnBefore > 0 {
+		return time.Duration(config.SessionRefresh.WarnBefore) * time.Second
+	}
+	return sessionDefaultWarnBefore
+}
+
+// refreshSession 按需刷新当前用户 (processingUser) 的登录状态, 并将更新后的 Cookie 写回配置文件.
+// 刷新失败或 MUSIC_U 即将过期时发出警告, 返回使用最新 Cookie 的请求数据
+func refreshSession(data utils.RequestData) utils.RequestData {
+	state := accountStates.Get(processingUser)
+	cookies := config.Users[processingUser].Cookies
+	expires := musicUExpires(cookies)
+	var refreshErr error
+	if config.SessionRefresh.Enabled && refreshDue(state, expires) {
+		newCookies, err := refreshToken(data)
+		if err == nil {
+			for _, c := range newCookies {
+				redactor.AddSecrets(c.Value)
+			}
+			cookies = mergeCookies(cookies, newCookies)
+			expires = musicUExpires(cookies)
+			config.Users[processingUser].Cookies = cookies
+			accountStates.Refreshed(processingUser, cookieHash(cookies), expires)
+			data.Cookies = accountCookies(processingUser, cookies)
+			if len(newCookies) == 0 {
+				log.Printf("已刷新 User[%d] 的登录状态", processingUser)
+			} else if err = saveUserCookies(*configFileName, processingUser, cookies); err != nil {
+				log.Warnf("已刷新 User[%d] 的登录状态, 但写入配置文件失败: %v, 重启后将使用原有的 Cookie", processingUser, err)
+			} else {
+				log.Printf("已刷新 User[%d] 的登录状态, 并将更新后的 Cookie 写入配置文件", processingUser)
+			}
+		} else {
+			refreshErr = err
+			log.Warnf("刷新 User[%d] 的登录状态失败: %v", processingUser, err)
+		}
+	}
+	expiring := !expires.IsZero() && time.Until(expires) < sessionWarnBefore()
+	if refreshErr != nil || expiring {
+		if accountStates.RefreshFailed(processingUser, cookieHash(cookies), refreshErr) {
+			warnSessionExpiring(accountStates.Get(processingUser), expires, refreshErr)
+		}
+	}
+	return data
+}
+
+// refreshDue 判断是否需要刷新登录状态: MUSIC_U 即将过期时每天尝试一次, 否则距上次刷新 (或开始使用该 Cookie) 超过刷新间隔时刷新
+func refreshDue(state AccountState, expires time.Time) bool {
+	if !expires.IsZero() && time.Until(expires) < sessionWarnBefore() {
+		return time.Since(state.LastRefresh) >= sessionRetryInterval
+	}
+	last := state.LastRefresh
+	if last.IsZero() {
+		last = state.ValidSince
+	}
+	return time.Since(last) >= sessionInterval()
+}
+
+// refreshToken 调用刷新登录状态 API, 返回服务器下发的新 Cookie
+func refreshToken(data utils.RequestData) ([]*http.Cookie, error) {
+	var options utils.EapiOption
+	options.Path = TokenRefreshAPI
+	options.Url = "https://music.163.com/eapi/login/token/refresh"
+	options.Json = "{}"
+	resBody, header, err := utils.ApiRequest(options, data)
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	err = json.Unmarshal([]byte(resBody), &result)
+	if err != nil {
+		return nil, fmt.Errorf("解析返回内容失败: %v", err)
+	}
+	if result.Code != 200 {
+		return nil, fmt.Errorf("代码: %d, 原因: \"%s\"", result.Code, result.Message)
+	}
+	return configfile.ParseCookies(header), nil
+}
+
+// mergeCookies 用新 Cookie 替换同名的旧 Cookie, 并添加新出现的 Cookie
+func mergeCookies(old, new []*http.Cookie) []*http.Cookie {
+	index := map[string]int{}
+	var result []*http.Cookie
+	for _, c := range old {
+		index[c.Name] = len(result)
+		result = append(result, c)
+	}
+	for _, c := range new {
+		if i, ok := index[c.Name]; ok {
+			result[i] = c
+			continue
+		}
+		index[c.Name] = len(result)
+		result = append(result, c)
+	}
+	return result
+}
+
+// warnSessionExpiring 推送登录状态刷新失败或即将过期的警告, 运行结果为 warning
+func warnSessionExpiring(state AccountState, expires time.Time, refreshErr error) {
+	notifiers := loadNotifiers()
+	if len(notifiers) == 0 {
+		return
+	}
+	account := &AccountReport{User: processingUser, UserID: state.UserID, Nickname: state.Nickname, CookieValid: true}
+	name := account.Name()
+	if state.UserID != 0 {
+		name = fmt.Sprintf("%s (ID: %d)", account.Name(), state.UserID)
+	}
+	var lines []string
+	if refreshErr != nil {
+		lines = append(lines, fmt.Sprintf("%s 的登录状态刷新失败: %v", name, refreshErr))
+	}
+	if !expires.IsZero() {
+		lines = append(lines, fmt.Sprintf("%s 的 MUSIC_U 将于 %s 过期, 剩余 %s",
+			name, expires.Local().Format("2006-01-02 15:04"), formatDuration(time.Until(expires))))
+	} else {
+		lines = append(lines, "当前 Cookie 仍然有效, 但可能随时失效")
+	}
+	account.Warnings = append(account.Warnings, lines...)
+	lines = append(lines, reloginHint())
+	sendNotification(notifiers, Notification{
+		Title:   "网易云音乐登录状态即将失效",
+		Content: strings.Join(lines, "\n"),
+		Report:  &RunReport{StartTime: time.Now(), EndTime: time.Now(), Accounts: []*AccountReport{account}},
+		Alert:   true,
+	})
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// AccountStatus 单个账号的运行状态
+type AccountStatus struct {
+	User          int             `json:"User"`
+	UserID        int             `json:"UserID"`
+	Nickname      string          `json:"Nickname"`
+	AvatarURL     string          `json:"AvatarURL,omitempty"`
+	CookieValid   bool            `json:"CookieValid"`
+	VipLevel      int             `json:"VipLevel"`
+	LastRun       time.Time       `json:"LastRun"`
+	LastResult    string          `json:"LastResult"`
+	LastError     string          `json:"LastError,omitempty"`
+	CloudBean     int             `json:"CloudBean"`
+	BeanHistory   []BeanPoint     `json:"BeanHistory,omitempty"`
+	Missions      []string        `json:"Missions"`
+	MissionStatus []MissionStatus `json:"MissionStatus,omitempty"`
+}
+
+// BeanPoint 云豆数变化记录
+type BeanPoint struct {
+	Time      time.Time `json:"Time"`
+	CloudBean int       `json:"CloudBean"`
+}
+
+// MissionStatus 音乐人任务状态
+type MissionStatus struct {
+	Description string `json:"Description"`
+	Weekly      bool   `json:"Weekly"`
+	Status      int    `json:"Status"`
+	Reward      string `json:"Reward"`
+}
+
+// maxBeanHistory 每个账号保留的云豆记录数
+const maxBeanHistory = 200
+
+// DaemonStatus 守护进程运行状态
+type DaemonStatus struct {
+	sync.Mutex
+	Running  bool
+	Ready    bool
+	LastRun  time.Time
+	NextRun  time.Time
+	Accounts map[int]*AccountStatus
+}
+
+var daemonStatus = DaemonStatus{Accounts: map[int]*AccountStatus{}}
+
+const (
+	// ResultSuccess 任务成功
+	ResultSuccess = "success"
+	// ResultFailed 任务失败
+	ResultFailed = "failed"
+	// ResultCookieInvalid Cookie 失效
+	ResultCookieInvalid = "cookie_invalid"
+)
+
+// Update 修改某个用户的状态
+func (d *DaemonStatus) Update(user int, f func(s *AccountStatus)) {
+	d.Lock()
+	defer d.Unlock()
+	s, ok := d.Accounts[user]
+	if !ok {
+		s = &AccountStatus{User: user}
+		d.Accounts[user] = s
+	}
+	f(s)
+}
+
+// SetRunning 设置是否正在运行任务
+func (d *DaemonStatus) SetRunning(running bool) {
+	d.Lock()
+	defer d.Unlock()
+	d.Running = running
+	if !running {
+		d.LastRun = time.Now()
+	}
+}
+
+// SetNextRun 设置下次运行时间, 同时标记为就绪
+func (d *DaemonStatus) SetNextRun(next time.Time) {
+	d.Lock()
+	defer d.Unlock()
+	d.NextRun = next
+	d.Ready = true
+}
+
+// IsReady 是否已就绪
+func (d *DaemonStatus) IsReady() bool {
+	d.Lock()
+	defer d.Unlock()
+	return d.Ready
+}
+
+// StatusSnapshot /status 接口返回数据
+type StatusSnapshot struct {
+	Running  bool            `json:"Running"`
+	LastRun  time.Time       `json:"LastRun"`
+	NextRun  time.Time       `json:"NextRun"`
+	Accounts []AccountStatus `json:"Accounts"`
+}
+
+// Snapshot 返回当前状态的副本
+func (d *DaemonStatus) Snapshot() StatusSnapshot {
+	d.Lock()
+	defer d.Unlock()
+	snapshot := StatusSnapshot{
+		Running:  d.Running,
+		LastRun:  d.LastRun,
+		NextRun:  d.NextRun,
+		Accounts: []AccountStatus{},
+	}
+	for _, s := range d.Accounts {
+		account := *s
+		account.Missions = append([]string{}, s.Missions...)
+		account.BeanHistory = append([]BeanPoint{}, s.BeanHistory...)
+		account.MissionStatus = append([]MissionStatus{}, s.MissionStatus...)
+		snapshot.Accounts = append(snapshot.Accounts, account)
+	}
+	sort.Slice(snapshot.Accounts, func(i, j int) bool {
+		return snapshot.Accounts[i].User < snapshot.Accounts[j].User
+	})
+	return snapshot
+}
+
+// Public 返回去掉昵称、头像、用户 ID、错误信息等账号信息后的状态, 用于未设置 Token 时的 /status 接口
+func (s StatusSnapshot) Public() StatusSnapshot {
+	for i, a := range s.Accounts {
+		s.Accounts[i] = AccountStatus{
+			User:        a.User,
+			CookieValid: a.CookieValid,
+			LastRun:     a.LastRun,
+			LastResult:  a.LastResult,
+			CloudBean:   a.CloudBean,
+			Missions:    a.Missions,
+		}
+	}
+	return s
+}
+
+// setRunResult 记录某个用户的运行结果
+func setRunResult(user int, err error) {
+	daemonStatus.Update(user, func(s *AccountStatus) {
+		s.LastRun = time.Now()
+		s.LastResult = ResultSuccess
+		s.LastError = ""
+		if err != nil {
+			s.LastResult = ResultFailed
+			s.LastError = redactor.Redact(err.Error())
+		}
+	})
+}
+
+// setCloudBeanStatus 更新当前用户的云豆数
+func setCloudBeanStatus(cloudBean int) {
+	daemonStatus.Update(processingUser, func(s *AccountStatus) {
+		s.CloudBean = cloudBean
+		s.BeanHistory = append(s.BeanHistory, BeanPoint{Time: time.Now(), CloudBean: cloudBean})
+		if len(s.BeanHistory) > maxBeanHistory {
+			s.BeanHistory = s.BeanHistory[len(s.BeanHistory)-maxBeanHistory:]
+		}
+	})
+}
+
+// setMissionStatus 更新当前用户的未完成任务及音乐人任务状态
+func setMissionStatus(autoTasks []string, missions []MissionStatus) {
+	daemonStatus.Update(processingUser, func(s *AccountStatus) {
+		s.Missions = autoTasks
+		s.MissionStatus = missions
+	})
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/XiaoMengXinX/Fuck163MusicTasks/v2/configfile"
+	"github.com/XiaoMengXinX/Music163Api-Go/api"
+	"github.com/XiaoMengXinX/Music163Api-Go/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// saveToConfig 将 Cookie 写入配置文件, 按用户 ID 匹配已有用户并更新其 Cookies, 不存在时添加新用户.
+// 只修改对应的 Cookies 部分, 保留配置文件的其他内容及格式, 并将原文件备份
+func saveToConfig(file string, userID int, cookies []configfile.Cookie) (index int, added bool, err error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return 0, false, err
+	}
+	users, err := configfile.ObjectValue(data, 0, "Users")
+	if err != nil {
+		return 0, false, err
+	}
+	elements, err := configfile.ArrayElements(data, users.Start)
+	if err != nil {
+		return 0, false, fmt.Errorf("解析 Users 失败: %v", err)
+	}
+
+	usersIndent := configfile.IndentOf(data, users.Start)
+	unit := "  "
+	if usersIndent != "" {
+		unit = usersIndent
+	}
+	if len(elements) != 0 {
+		if u := strings.TrimPrefix(configfile.IndentOf(data, elements[0].Start), usersIndent); u != "" {
+			unit = u
+		}
+	}
+
+	index = matchUser(file, data, elements, userID, cookies)
+	var newData []byte
+	if index >= 0 {
+		newData, err = configfile.SetObjectValue(data, elements[index], "Cookies", cookies, unit)
+		if err != nil {
+			return 0, false, err
+		}
+	} else {
+		index = len(elements)
+		added = true
+		elementIndent := usersIndent + unit
+		value, err := json.MarshalIndent(map[string]interface{}{"Cookies": cookies}, elementIndent, unit)
+		if err != nil {
+			return 0, false, err
+		}
+		if len(elements) != 0 {
+			last := elements[len(elements)-1]
+			newData = configfile.Replace(data, configfile.Span{Start: last.End, End: last.End}, []byte(",\n"+elementIndent+string(value)))
+		} else {
+			newData = configfile.Replace(data, users, []byte("[\n"+elementIndent+string(value)+"\n"+usersIndent+"]"))
+		}
+	}
+	if !json.Valid(newData) {
+		return 0, false, fmt.Errorf("生成的配置文件格式错误, 未修改配置文件")
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return 0, false, err
+	}
+	backup, err := configfile.WriteBackup(file, data, info.Mode().Perm())
+	if err != nil {
+		return 0, false, fmt.Errorf("备份配置文件失败: %v", err)
+	}
+	log.Printf("已将原配置文件备份到 %s", backup)
+	tmpFile := file + ".tmp"
+	err = ioutil.WriteFile(tmpFile, newData, info.Mode().Perm())
+	if err != nil {
+		return 0, false, err
+	}
+	return index, added, os.Rename(tmpFile, file)
+}
+
+// matchUser 查找与 userID 对应的已有用户, 依次按相同的 MUSIC_U、GetLoginStatus 返回的用户 ID、主程序记录的账号状态匹配
+func matchUser(file string, data []byte, elements []configfile.Span, userID int, cookies []configfile.Cookie) int {
+	var musicU string
+	for _, c := range cookies {
+		if c.Name == "MUSIC_U" {
+			musicU = c.Value
+		}
+	}
+	userCookies := make([][]*http.Cookie, len(elements))
+	for i, e := range elements {
+		var user struct {
+			Cookies []*http.Cookie `json:"Cookies"`
+		}
+		if json.Unmarshal(data[e.Start:e.End], &user) != nil {
+			continue
+		}
+		userCookies[i] = user.Cookies
+		for _, c := range user.Cookies {
+			if c.Name == "MUSIC_U" && c.Value == musicU {
+				return i
+			}
+		}
+	}
+	for i, c := range userCookies {
+		if len(c) == 0 {
+			continue
+		}
+		status, err := api.GetLoginStatus(utils.RequestData{Cookies: c})
+		if err == nil && status.Account.Id == userID {
+			return i
+		}
+	}
+	for i, id := range knownUserIDs(file, data) {
+		if id == userID && i < len(elements) {
+			return i
+		}
+	}
+	return -1
+}
+
+// knownUserIDs 读取主程序在数据目录中记录的各账号用户 ID, 用于匹配 Cookie 已失效的用户.
+// 与主程序相同, DataDir 为相对路径时相对于配置文件所在目录
+func knownUserIDs(file string, data []byte) map[int]int {
+	var cfg struct {
+		DataDir string `json:"DataDir"`
+	}
+	_ = json.Unmarshal(data, &cfg)
+	if cfg.DataDir == "" {
+		cfg.DataDir = "data"
+	}
+	if !filepath.IsAbs(cfg.DataDir) {
+		cfg.DataDir = filepath.Join(filepath.Dir(file), cfg.DataDir)
+	}
+	fileData, err := ioutil.ReadFile(filepath.Join(cfg.DataDir, "accounts.json"))
+	if err != nil {
+		return nil
+	}
+	var accounts map[string]struct {
+		UserID int `json:"UserID"`
+	}
+	if json.Unmarshal(fileData, &accounts) != nil {
+		return nil
+	}
+	ids := map[int]int{}
+	for k, a := range accounts {
+		if i, err := strconv.Atoi(k); err == nil && a.UserID != 0 {
+			ids[i] = a.UserID
+		}
+	}
+	return ids
+}
@@ -3,7 +3,12 @@ module github.com/XiaoMengXinX/Fuck163MusicTasks/tools/quickLogin
 go 1.16
 
 require (
-	github.com/XiaoMengXinX/Music163Api-Go v0.1.16
+	github.com/XiaoMengXinX/Fuck163MusicTasks/v2 v2.0.0-00010101000000-000000000000
+	github.com/XiaoMengXinX/Music163Api-Go v0.1.29
 	github.com/sirupsen/logrus v1.8.1
 	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
+	golang.org/x/sys v0.0.0-20220310020820-b874c991c1a5 // indirect
+	golang.org/x/term v0.0.0-20210422114643-f5beecf764ed
 )
+
+replace github.com/XiaoMengXinX/Fuck163MusicTasks/v2 => ../..
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/XiaoMengXinX/Fuck163MusicTasks/v2/configfile"
+	"github.com/XiaoMengXinX/Music163Api-Go/utils"
+	"golang.org/x/term"
+)
+
+// passwordEnv 从此环境变量读取登录密码, 用于无法交互输入的场景
+const passwordEnv = "QUICKLOGIN_PASSWORD"
+
+const (
+	// SentCaptchaAPI 发送短信验证码 API
+	SentCaptchaAPI = "/api/sms/captcha/sent"
+	// CellphoneLoginAPI 手机号登录 API
+	CellphoneLoginAPI = "/api/w/login/cellphone"
+	// EmailLoginAPI 邮箱登录 API
+	EmailLoginAPI = "/api/w/login"
+)
+
+// loginResult 登录相关 API 返回数据
+type loginResult struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Msg     string `json:"msg"`
+	Profile struct {
+		Nickname string `json:"nickname"`
+	} `json:"profile"`
+}
+
+// error 根据返回代码生成错误信息, 对风控及需要验证的情况给出提示
+func (r loginResult) error() error {
+	message := r.Message
+	if message == "" {
+		message = r.Msg
+	}
+	switch r.Code {
+	case 200:
+		return nil
+	case 8810, 8821:
+		return fmt.Errorf("触发网易云风控, 需要进行安全验证 (代码: %d), 请改用扫码登录或稍后再试", r.Code)
+	case 10004:
+		return fmt.Errorf("当前 IP 登录异常, 需要进行安全验证 (代码: %d), 请改用扫码登录", r.Code)
+	case 501:
+		return fmt.Errorf("账号不存在")
+	case 502:
+		return fmt.Errorf("密码错误")
+	case 503:
+		return fmt.Errorf("验证码错误")
+	case 509:
+		return fmt.Errorf("密码错误次数过多, 请稍后再试或改用扫码登录")
+	}
+	return fmt.Errorf("代码: %d, 原因: \"%s\"", r.Code, message)
+}
+
+// loginRequest 发送登录相关请求, 返回解析后的结果及返回的 Header
+func loginRequest(path string, body interface{}) (result loginResult, header http.Header, err error) {
+	var options utils.EapiOption
+	options.Path = path
+	options.Url = "https://music.163.com/eapi" + strings.TrimPrefix(path, "/api")
+	reqBody, _ := json.Marshal(body)
+	options.Json = string(reqBody)
+	resBody, header, err := utils.ApiRequest(options, utils.RequestData{})
+	if err != nil {
+		return result, header, err
+	}
+	err = json.Unmarshal([]byte(resBody), &result)
+	if err != nil {
+		return result, header, fmt.Errorf("解析返回内容失败: %v", err)
+	}
+	return result, header, result.error()
+}
+
+// sentCaptcha 发送短信验证码
+func sentCaptcha(cellphone, countryCode string) error {
+	_, _, err := loginRequest(SentCaptchaAPI, map[string]string{
+		"cellphone": cellphone,
+		"ctcode":    countryCode,
+	})
+	return err
+}
+
+// cellphoneLogin 手机号登录, captcha 不为空时使用短信验证码登录, 否则使用密码登录
+func cellphoneLogin(cellphone, countryCode, password, captcha string) ([]*http.Cookie, error) {
+	body := map[string]string{
+		"phone":         cellphone,
+		"countrycode":   countryCode,
+		"rememberLogin": "true",
+	}
+	if captcha != "" {
+		body["captcha"] = captcha
+	} else {
+		body["password"] = md5Hex(password)
+	}
+	result, header, err := loginRequest(CellphoneLoginAPI, body)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("[%s] 登录成功\n", result.Profile.Nickname)
+	return configfile.ParseCookies(header), nil
+}
+
+// emailLogin 邮箱密码登录
+func emailLogin(email, password string) ([]*http.Cookie, error) {
+	result, header, err := loginRequest(EmailLoginAPI, map[string]string{
+		"username":      email,
+		"password":      md5Hex(password),
+		"rememberLogin": "true",
+	})
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("[%s] 登录成功\n", result.Profile.Nickname)
+	return configfile.ParseCookies(header), nil
+}
+
+// accountLogin 按命令行参数使用手机号或邮箱登录
+func accountLogin(ctx context.Context) ([]*http.Cookie, error) {
+	if *email != "" {
+		password, err := readPassword(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return emailLogin(*email, password)
+	}
+	if *sms {
+		err := sentCaptcha(*phone, *countryCode)
+		if err != nil {
+			return nil, fmt.Errorf("发送验证码失败: %v", err)
+		}
+		fmt.Printf("验证码已发送到 +%s %s\n", *countryCode, *phone)
+		captcha, err := prompt(ctx, "请输入短信验证码: ")
+		if err != nil {
+			return nil, err
+		}
+		return cellphoneLogin(*phone, *countryCode, "", captcha)
+	}
+	password, err := readPassword(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return cellphoneLogin(*phone, *countryCode, password, "")
+}
+
+// readPassword 返回环境变量 QUICKLOGIN_PASSWORD 中的密码, 未设置时从终端读取 (不回显), 可被 ctx 取消
+func readPassword(ctx context.Context) (string, error) {
+	if s := os.Getenv(passwordEnv); s != "" {
+		return s, nil
+	}
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return readLine(ctx, "请输入密码: ", trimNewline)
+	}
+	state, err := term.GetState(fd)
+	if err != nil {
+		return "", err
+	}
+	fmt.Print("请输入密码: ")
+	type result struct {
+		s   string
+		err error
+	}
+	line := make(chan result, 1)
+	go func() {
+		b, err := term.ReadPassword(fd)
+		line <- result{trimNewline(string(b)), err}
+	}()
+	select {
+	case <-ctx.Done():
+		_ = term.Restore(fd, state) // 恢复回显, 否则退出后终端不显示输入
+		fmt.Println()
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", fmt.Errorf("等待输入超时 (%s)", *timeout)
+		}
+		return "", fmt.Errorf("已取消登录: %w", ctx.Err())
+	case r := <-line:
+		fmt.Println()
+		if r.err != nil {
+			return "", r.err
+		}
+		if r.s == "" {
+			return "", fmt.Errorf("输入不能为空")
+		}
+		return r.s, nil
+	}
+}
+
+// prompt 从标准输入读取一行并去掉首尾空白, 可被 ctx 取消
+func prompt(ctx context.Context, text string) (string, error) {
+	return readLine(ctx, text, strings.TrimSpace)
+}
+
+// trimNewline 只去掉行尾的换行符, 密码中的空格会原样保留
+func trimNewline(s string) string {
+	return strings.TrimRight(s, "\r\n")
+}
+
+// readLine 从标准输入读取一行并用 trim 处理, 可被 ctx 取消
+func readLine(ctx context.Context, text string, trim func(string) string) (string, error) {
+	fmt.Print(text)
+	line := make(chan string, 1)
+	go func() {
+		s, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		line <- trim(s)
+	}()
+	select {
+	case <-ctx.Done():
+		fmt.Println()
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", fmt.Errorf("等待输入超时 (%s)", *timeout)
+		}
+		return "", fmt.Errorf("已取消登录: %w", ctx.Err())
+	case s := <-line:
+		if s == "" {
+			return "", fmt.Errorf("输入不能为空")
+		}
+		return s, nil
+	}
+}
+
+// md5Hex 返回 MD5 的十六进制字符串
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
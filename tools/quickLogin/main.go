@@ -1,17 +1,22 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"github.com/XiaoMengXinX/Fuck163MusicTasks/v2/configfile"
 	"github.com/XiaoMengXinX/Music163Api-Go/api"
 	"github.com/XiaoMengXinX/Music163Api-Go/utils"
 	log "github.com/sirupsen/logrus"
-	"github.com/skip2/go-qrcode"
 	"io"
+	"net/http"
 	"os"
+	"os/signal"
 	"path"
-	"regexp"
 	"strings"
+	"syscall"
 	"time"
 )
 
@@ -46,47 +51,103 @@ func init() {
 }
 
 var (
-	isDEBUG      = flag.Bool("d", false, "DEBUG mode")
-	cookieParser = regexp.MustCompile(`MUSIC_U=(.*?);`)
+	isDEBUG     = flag.Bool("d", false, "DEBUG mode")
+	configFile  = flag.String("c", "", "登录成功后将 Cookie 写入该配置文件")
+	pngFile     = flag.String("png", "", "将二维码保存为 PNG 图片")
+	httpAddr    = flag.String("http", "", "启动显示二维码及扫码状态的本地网页, 如 127.0.0.1:8163")
+	timeout     = flag.Duration("timeout", 5*time.Minute, "登录超时时间, 为 0 时不限制")
+	maxRefresh  = flag.Int("refresh", 3, "二维码过期后最多重新生成的次数")
+	phone       = flag.String("phone", "", "使用手机号登录, 默认使用密码登录")
+	countryCode = flag.String("country", "86", "手机号的国家代码")
+	sms         = flag.Bool("sms", false, "使用短信验证码登录, 需同时指定 -phone")
+	email       = flag.String("email", "", "使用邮箱及密码登录")
+)
+
+const (
+	exitFailed   = 1   // 登录失败或超时
+	exitCanceled = 130 // 按下 Ctrl-C 取消登录
 )
 
 func main() {
-	qrKey, err := api.GetQrUnikey(utils.RequestData{})
-	if err != nil {
-		log.Fatal(err)
+	os.Exit(run())
+}
+
+// run 登录并处理获得的 Cookie, 返回进程退出码
+func run() int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
 	}
-	qr, err := qrcode.New(fmt.Sprintf("https://music.163.com/login?codekey=%s", qrKey.Unikey), qrcode.High)
-	if err != nil {
-		log.Fatal(err)
+	if *sms && *phone == "" {
+		log.Errorln("使用短信验证码登录时需指定 -phone")
+		return exitFailed
 	}
-	fmt.Println(qr.ToSmallString(false))
-	fmt.Println("请使用网易云手机客户端扫描二维码")
-	for {
-		loginData, header, err := api.CheckQrLogin(utils.RequestData{}, qrKey.Unikey)
-		if err != nil {
-			log.Fatal(err)
+	var cookies []*http.Cookie
+	var err error
+	if *phone != "" || *email != "" {
+		cookies, err = accountLogin(ctx)
+	} else {
+		if *httpAddr != "" {
+			if err := startQrServer(*httpAddr); err != nil {
+				log.Errorln(err)
+				return exitFailed
+			}
 		}
-		if loginData.Code == 802 {
-			fmt.Println(loginData.Message)
+		cookies, err = qrLogin(ctx)
+	}
+	if err != nil {
+		log.Errorln(err)
+		if errors.Is(err, context.Canceled) {
+			return exitCanceled
 		}
-		if loginData.Code == 803 {
-			fmt.Println(loginData.Message)
-			cookies := header.Values("Set-Cookie")
-			var musicU string
-			for _, cookie := range cookies {
-				str := cookieParser.FindString(cookie)
-				if str != "" {
-					musicU = strings.Trim(strings.Trim(str, "MUSIC_U="), ";")
-					break
-				}
-			}
-			if musicU != "" {
-				fmt.Printf("[MUSIC_U] %s\n", musicU)
-			} else {
-				log.Errorln("解析 MUSIC_U 失败，请重新登陆")
-			}
-			break
+		return exitFailed
+	}
+	var musicU string
+	for _, cookie := range cookies {
+		if cookie.Name == "MUSIC_U" {
+			musicU = cookie.Value
 		}
-		time.Sleep(time.Duration(1) * time.Second)
 	}
+	if musicU == "" {
+		log.Errorln("解析 MUSIC_U 失败，请重新登陆")
+		return exitFailed
+	}
+	fmt.Printf("[MUSIC_U] %s\n", musicU)
+	if *configFile != "" {
+		if err = writeConfig(cookies); err != nil {
+			log.Errorln(err)
+			return exitFailed
+		}
+	} else {
+		cookiesJSON, _ := json.MarshalIndent(configfile.Cookies(cookies), "", "  ")
+		fmt.Printf("[Cookies] (可直接填入配置文件的 Users[].Cookies)\n%s\n", cookiesJSON)
+	}
+	if *httpAddr != "" && *phone == "" && *email == "" {
+		time.Sleep(2 * time.Second) // 等待网页显示登录成功
+	}
+	return 0
+}
+
+// writeConfig 获取登录用户的信息, 并将 Cookie 写入配置文件
+func writeConfig(cookies []*http.Cookie) error {
+	loginStatus, err := api.GetLoginStatus(utils.RequestData{Cookies: cookies})
+	if err != nil {
+		return err
+	}
+	if loginStatus.Account.Id == 0 {
+		return fmt.Errorf("获取登录状态失败, 未写入配置文件")
+	}
+	index, added, err := saveToConfig(*configFile, loginStatus.Account.Id, configfile.Cookies(cookies))
+	if err != nil {
+		return fmt.Errorf("写入配置文件失败: %v", err)
+	}
+	if added {
+		log.Printf("已将 [%s] 添加为配置文件中的 User[%d]", loginStatus.Profile.Nickname, index)
+	} else {
+		log.Printf("已更新配置文件中 User[%d] [%s] 的 Cookie", index, loginStatus.Profile.Nickname)
+	}
+	return nil
 }
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/XiaoMengXinX/Fuck163MusicTasks/v2/configfile"
+	"github.com/XiaoMengXinX/Music163Api-Go/api"
+	"github.com/XiaoMengXinX/Music163Api-Go/utils"
+	log "github.com/sirupsen/logrus"
+	"github.com/skip2/go-qrcode"
+)
+
+// qrStatus 当前二维码及扫码状态, 供网页显示
+type qrStatus struct {
+	sync.Mutex
+	png      []byte
+	Version  int    `json:"version"`
+	Code     int    `json:"code"`
+	Message  string `json:"message"`
+	Nickname string `json:"nickname"`
+}
+
+var status qrStatus
+
+// SetQrCode 更新二维码图片
+func (s *qrStatus) SetQrCode(png []byte) {
+	s.Lock()
+	defer s.Unlock()
+	s.png = png
+	s.Version++
+	s.Code = 801
+	s.Message = "等待扫码"
+	s.Nickname = ""
+}
+
+// SetState 更新扫码状态
+func (s *qrStatus) SetState(code int, message, nickname string) {
+	s.Lock()
+	defer s.Unlock()
+	s.Code = code
+	s.Message = message
+	s.Nickname = nickname
+}
+
+// qrLogin 扫码登录, 返回登录成功后的 Cookie. 二维码过期后自动重新生成, 最多 maxRefresh 次
+func qrLogin(ctx context.Context) ([]*http.Cookie, error) {
+	qrKey, err := newQrKey()
+	if err != nil {
+		return nil, fmt.Errorf("获取二维码失败: %v", err)
+	}
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	var lastCode, refreshed, failures int
+	for {
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, fmt.Errorf("等待扫码超时 (%s)", *timeout)
+			}
+			return nil, fmt.Errorf("已取消登录: %w", ctx.Err())
+		case <-ticker.C:
+		}
+		loginData, header, err := api.CheckQrLogin(utils.RequestData{}, qrKey)
+		if err != nil {
+			failures++
+			if failures >= 5 {
+				return nil, fmt.Errorf("获取扫码状态失败: %v", err)
+			}
+			log.Warnf("获取扫码状态失败: %v", err)
+			continue
+		}
+		failures = 0
+		status.SetState(loginData.Code, loginData.Message, loginData.Nickname)
+		changed := loginData.Code != lastCode
+		lastCode = loginData.Code
+		switch loginData.Code {
+		case 801: // 等待扫码
+		case 802: // 已扫码, 等待确认
+			if changed {
+				fmt.Printf("[%s] 已扫码, 请在手机上确认登录\n", loginData.Nickname)
+			}
+		case 803: // 登录成功
+			fmt.Println(loginData.Message)
+			return configfile.ParseCookies(header), nil
+		case 800: // 二维码已过期
+			if refreshed >= *maxRefresh {
+				return nil, fmt.Errorf("二维码已过期, 已重新生成 %d 次, 请重新运行", refreshed)
+			}
+			refreshed++
+			fmt.Printf("二维码已过期, 正在重新生成 (%d/%d)\n", refreshed, *maxRefresh)
+			qrKey, err = newQrKey()
+			if err != nil {
+				return nil, fmt.Errorf("获取二维码失败: %v", err)
+			}
+			lastCode = 0
+		default:
+			return nil, fmt.Errorf("未知的扫码状态, 代码: %d, 原因: \"%s\"", loginData.Code, loginData.Message)
+		}
+	}
+}
+
+// newQrKey 获取新的二维码 unikey 并显示二维码
+func newQrKey() (string, error) {
+	qrKey, err := api.GetQrUnikey(utils.RequestData{})
+	if err != nil {
+		return "", err
+	}
+	return qrKey.Unikey, showQrCode(qrKey.Unikey)
+}
+
+// showQrCode 在终端显示二维码, 并按需保存为 PNG 图片
+func showQrCode(unikey string) error {
+	qr, err := qrcode.New(fmt.Sprintf("https://music.163.com/login?codekey=%s", unikey), qrcode.High)
+	if err != nil {
+		return err
+	}
+	png, err := qr.PNG(320)
+	if err != nil {
+		return err
+	}
+	status.SetQrCode(png)
+	fmt.Println(qr.ToSmallString(false))
+	if *pngFile != "" {
+		err = ioutil.WriteFile(*pngFile, png, 0644)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("二维码已保存到 %s\n", *pngFile)
+	}
+	fmt.Println("请使用网易云手机客户端扫描二维码")
+	return nil
+}
+
+// startQrServer 启动显示二维码及扫码状态的本地网页
+func startQrServer(addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(qrPage))
+	})
+	mux.HandleFunc("/qr.png", func(w http.ResponseWriter, r *http.Request) {
+		status.Lock()
+		png := status.png
+		status.Unlock()
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Content-Length", strconv.Itoa(len(png)))
+		_, _ = w.Write(png)
+	})
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		status.Lock()
+		data, _ := json.Marshal(&status)
+		status.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		_, _ = w.Write(data)
+	})
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := http.Serve(ln, mux); err != nil {
+			log.Errorln(err)
+		}
+	}()
+	fmt.Printf("请在浏览器中打开 http://%s 扫描二维码\n", ln.Addr())
+	return nil
+}
+
+// qrPage 显示二维码的网页, 每秒刷新扫码状态, 二维码更新后自动刷新图片
+const qrPage = `<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <title>网易云音乐扫码登录</title>
+  <style>
+    body { font-family: -apple-system, "Segoe UI", "PingFang SC", "Microsoft YaHei", sans-serif; background: #f5f5f5; color: #333; text-align: center; padding-top: 48px; }
+    .card { display: inline-block; background: #fff; border-radius: 8px; padding: 24px 32px; box-shadow: 0 1px 3px rgba(0, 0, 0, .1); }
+    h1 { font-size: 18px; color: #c20c0c; }
+    img { width: 240px; height: 240px; }
+    #status { font-size: 15px; margin-top: 12px; }
+    .ok { color: #23862f; }
+    .bad { color: #c20c0c; }
+  </style>
+</head>
+<body>
+<div class="card">
+  <h1>请使用网易云音乐手机客户端扫码登录</h1>
+  <img id="qr" src="qr.png?v=1" alt="二维码">
+  <div id="status">等待扫码</div>
+</div>
+<script>
+  var version = 1;
+  var texts = {800: "二维码已过期, 正在重新生成", 801: "等待扫码", 802: "已扫码, 请在手机上确认登录", 803: "登录成功, 可以关闭此页面"};
+  function refresh() {
+    fetch("status", {cache: "no-store"}).then(function (res) { return res.json(); }).then(function (s) {
+      if (s.version !== version) {
+        version = s.version;
+        document.getElementById("qr").src = "qr.png?v=" + version;
+      }
+      var el = document.getElementById("status");
+      var text = texts[s.code] || s.message || ("未知状态 " + s.code);
+      if (s.code === 802 && s.nickname) {
+        text = s.nickname + " " + text;
+      }
+      el.textContent = text;
+      el.className = s.code === 803 ? "ok" : (s.code === 800 ? "bad" : "");
+      if (s.code !== 803) {
+        setTimeout(refresh, 1000);
+      }
+    }).catch(function () {
+      document.getElementById("status").textContent = "QuickLogin 已退出";
+    });
+  }
+  refresh();
+</script>
+</body>
+</html>
+`
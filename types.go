@@ -6,9 +6,14 @@ import (
 
 // Config 配置文件结构
 type Config struct {
-	DEBUG bool `json:"DEBUG"`
-	Users []struct {
+	DEBUG    bool      `json:"DEBUG"`
+	DataDir  string    `json:"DataDir"`
+	AuditLog string    `json:"AuditLog"`
+	Log      LogConfig `json:"Log"`
+	Users    []struct {
 		Cookies []*http.Cookie `json:"Cookies"`
+		Proxy   string         `json:"Proxy"`
+		Device  DeviceConfig   `json:"Device"`
 	} `json:"Users"`
 	MusicShareConfig struct {
 		MySongID int `json:"MySongID"`
@@ -40,10 +45,37 @@ type Config struct {
 		EnableLag  bool      `json:"EnableLag"`
 		LagConfig  LagConfig `json:"LagConfig"`
 	} `json:"Cron"`
+	Server struct {
+		Enabled   bool   `json:"Enabled"`
+		Listen    string `json:"Listen"`
+		Dashboard bool   `json:"Dashboard"`
+		Token     string `json:"Token"`
+	} `json:"Server"`
+	Notifiers []NotifierConfig `json:"Notifiers"`
+	Outbox    struct {
+		MaxAge int `json:"MaxAge"`
+	} `json:"Outbox"`
+	SessionRefresh struct {
+		Enabled    bool `json:"Enabled"`
+		Interval   int  `json:"Interval"`
+		WarnBefore int  `json:"WarnBefore"`
+	} `json:"SessionRefresh"`
 	PushPlusToken string `json:"PushPlusToken"`
 	ServerSendKey string `json:"ServerSendKey"`
 }
 
+// LogConfig 日志设置
+type LogConfig struct {
+	Dir        string `json:"Dir"`
+	StdoutOnly bool   `json:"StdoutOnly"`
+	Format     string `json:"Format"`
+	Rotate     string `json:"Rotate"`
+	MaxSize    int    `json:"MaxSize"`
+	MaxAge     int    `json:"MaxAge"`
+	MaxBackups int    `json:"MaxBackups"`
+	Compress   bool   `json:"Compress"`
+}
+
 // LagConfig 延迟设置
 type LagConfig struct {
 	LagBetweenSendAndDelete bool `json:"LagBetweenSendAndDelete"`